@@ -6,20 +6,19 @@ validating itself you should first define template (which, to be honest, is the
 difficult part of the work) in the yaml-file which then will be parsed and used as
 validator-tool.
 But there are some limitations:
-  - maps can't nest within each other (which should be handled by making
-    a new node/edge that contains nested map etc.)
-  - arrays can't nest within each other (for the same reason as above)
-  - maps can't nest within arrays and vise versa (for the same reason as above)
+  - maps and arrays can nest within each other ONLY if data type is defined
+    in recursive notation - "array<value type>" or "map<key type,value type>"
   - map's and array's values (and keys in case of maps) can contain only the
-    same PRIMITIVE (int, string, etc) data types (for reasons of go data
-    types compatibility)
-  - labels can't nest within each other (which may lead to endless data-nesting and
-    implicit property- and connection-definitions which may cause hard-to-find
-    definition conflicts)
+    same data types (for reasons of go data types compatibility); map's keys
+    can be only PRIMITIVE (int, string, etc) data types
+  - labels can inherit each other, but inheritance can't be cyclic and unrelated
+    labels of the same node (or label) can't define the same property differently
   - graph-data can't contain 2 or more identical nodes (only one instance of node
-    will be created)
+    will be created); nodes are identical if ALL their properties are equal or
+    if their key properties are equal (see NewKeyedGraph)
   - graph-data can't contain 2 or more identical edges with identical directions
-    between one unique pair of nodes (only one instance of edge will be created)
+    between one unique pair of nodes (only one instance of edge will be created);
+    identical undirected edges can't be created in both directions
 
 Otherwise, public interface contains only few types and functions which should make
 ease to use this library!
@@ -32,16 +31,34 @@ Types:
 	Triplet
 	Duplet
 	Graph
+	Updater
+	Keys
+	TemplateBuilder
+	DataType
+	ValidationError
+	ErrorCode
+	ValidationReport
 
 Functions:
 
 	ParseTemplate(template-file) Validator
+	ParseTemplateFS(file system, root template-file path) Validator
+	RegisterType(name, custom data type) error
+	RegisterValidator(name, validator func) error
+	NewTemplate() TemplateBuilder
 	NewNode(type, properties) Node
 	NewEdge(type, properties) Edge
+	NewUndirectedEdge(type, properties) Edge
 	NewTriplet(main node, subject node, edge) Triplet
 	NewDuplet(node, edge) Duplet
 	NewGraph(triplets) Graph
+	NewKeyedGraph(keys, nodes, triplets) Graph
+	GraphKeys(validator) Keys
 	Validate(validator, any graph entity) bool, error
+	ValidateAll(validator, any graph entity, limit) bool, error
+	ApplyDefaults(validator, node) Node
+	ApplyEdgeDefaults(validator, edge) Edge
+	ApplyGraphDefaults(validator, graph) Graph
 
 As simple as it looks!
 */
@@ -49,6 +66,8 @@ package stg
 
 import (
 	"io"
+	"io/fs"
+	"stg/template"
 	"stg/template/parser"
 	"stg/validation"
 )
@@ -83,8 +102,248 @@ type (
 	// fully functional inmemory DB; also, because of this Graph-interface should
 	// NOT allow to contain identical nodes and edges within implementing data type
 	Graph = validation.Graph
+	// Updater interface - optional extension of Graph-interface, which replaces
+	// nodes and edges of graph by identical ones keeping their interconnections;
+	// graphs which are created by NewGraph and NewKeyedGraph implement it
+	Updater = validation.Updater
+	// Keys contains names of key properties by type names of nodes and edges;
+	// two nodes (or edges) of the same type are identical within graph if
+	// values of their key properties are equal
+	Keys = validation.Keys
+	// DataType interface - represents custom data type (e.g. money or semantic
+	// version) which supplies assertion of validated values, parsing of values
+	// of template and comparison of values; registered custom data type may be
+	// used within templates the same way as "simple" data types (see RegisterType)
+	DataType = template.DataType
+	// ValidatorFunc represents custom validator which may be referenced by
+	// name within validators-restriction of template (see RegisterValidator);
+	// it receives validated value and returns nil if value is valid
+	ValidatorFunc = template.ValidatorFunc
+	// ValidationError is the error which is returned by validation of nodes,
+	// edges, duplets, triplets and graphs; it contains machine-readable code of
+	// error and describes invalid entity, property and value; it may be wrapped
+	// by errors of entities which contain it, so it should be obtained using
+	// errors.As
+	ValidationError = template.ValidationError
+	// ErrorCode represents stable machine-readable code of ValidationError
+	ErrorCode = template.ErrorCode
+	// ValidationReport is the error which is returned by ValidateAll - it
+	// contains ALL found errors grouped by invalid entities (see EntityErrors)
+	ValidationReport = template.ValidationReport
+	// EntityErrors contains errors of the single invalid entity within
+	// ValidationReport
+	EntityErrors = template.EntityErrors
 )
 
+// Codes of validation errors (see ValidationError)
+const (
+	ErrUnknownType     = template.ErrUnknownType
+	ErrUnknownValue    = template.ErrUnknownValue
+	ErrExtraProperty   = template.ErrExtraProperty
+	ErrMissingProperty = template.ErrMissingProperty
+	ErrNullValue       = template.ErrNullValue
+	ErrDataType        = template.ErrDataType
+	ErrRestriction     = template.ErrRestriction
+	ErrCondition       = template.ErrCondition
+	ErrConstraint      = template.ErrConstraint
+	ErrConnection      = template.ErrConnection
+	ErrCardinality     = template.ErrCardinality
+	ErrUnique          = template.ErrUnique
+	ErrStructure       = template.ErrStructure
+)
+
+// Builder shortcuts
+type (
+	// TemplateBuilder builds template from Go code instead of template-file;
+	// all definitions are checked the same way as definitions of template-file
+	// at the end of building process (see TemplateBuilder.Build method)
+	TemplateBuilder = parser.TemplateBuilder
+	// PropOption represents option of property definition (optionality
+	// definitions, default value or restriction) within TemplateBuilder
+	PropOption = parser.PropOption
+)
+
+// Names of "primitive" data types which are used within TemplateBuilder
+const (
+	Int      = parser.Int
+	Float    = parser.Float
+	String   = parser.String
+	Bool     = parser.Bool
+	DateTime = parser.DateTime
+	Int64    = parser.Int64
+	Uint     = parser.Uint
+	Duration = parser.Duration
+	Date     = parser.Date
+	UUID     = parser.UUID
+	Bytes    = parser.Bytes
+)
+
+// Returns name of decimal data type with precision (maximum amount of digits)
+// and scale (amount of digits after decimal point); 0 precision means that
+// decimal values aren't restricted
+func Decimal(precision, scale int) string {
+	return parser.Decimal(precision, scale)
+}
+
+// Returns name of array data type with values of val data type (which may be
+// any data type including nested arrays and maps)
+func Array(val string) string {
+	return parser.Array(val)
+}
+
+// Returns name of map data type with keys of key "primitive" data type and
+// values of val data type (which may be any data type including nested arrays
+// and maps)
+func Map(key, val string) string {
+	return parser.Map(key, val)
+}
+
+// Returns option of TemplateBuilder which marks property as not required
+func Optional() PropOption {
+	return parser.Optional()
+}
+
+// Returns option of TemplateBuilder which marks property as required; it's
+// useful to redefine optionality of property of named data type
+func Required() PropOption {
+	return parser.Required()
+}
+
+// Returns option of TemplateBuilder which marks property as nullable
+func Nullable() PropOption {
+	return parser.Nullable()
+}
+
+// Returns option of TemplateBuilder which sets v default value of property
+func Default(v interface{}) PropOption {
+	return parser.Default(v)
+}
+
+// Returns option of TemplateBuilder which appends vs exact values
+// restrictions
+func Values(vs ...interface{}) PropOption {
+	return parser.Values(vs...)
+}
+
+// Returns option of TemplateBuilder which appends rs regexps restrictions
+func Regexps(rs ...string) PropOption {
+	return parser.Regexps(rs...)
+}
+
+// Returns option of TemplateBuilder which appends vs validators restrictions
+// (names of registered validators, see RegisterValidator)
+func Validators(vs ...string) PropOption {
+	return parser.Validators(vs...)
+}
+
+// Returns option of TemplateBuilder which appends vs exact values restrictions
+// of map keys
+func KeyValues(vs ...interface{}) PropOption {
+	return parser.KeyValues(vs...)
+}
+
+// Returns option of TemplateBuilder which appends rs regexps restrictions of
+// map keys
+func KeyRegexps(rs ...string) PropOption {
+	return parser.KeyRegexps(rs...)
+}
+
+// Returns option of TemplateBuilder which sets inclusive lower and upper
+// bounds restrictions
+func Range(min, max interface{}) PropOption {
+	return parser.Range(min, max)
+}
+
+// Returns option of TemplateBuilder which sets inclusive lower bound
+// restriction
+func Min(v interface{}) PropOption {
+	return parser.Min(v)
+}
+
+// Returns option of TemplateBuilder which sets inclusive upper bound
+// restriction
+func Max(v interface{}) PropOption {
+	return parser.Max(v)
+}
+
+// Returns option of TemplateBuilder which sets exclusive lower bound
+// restriction
+func ExclusiveMin(v interface{}) PropOption {
+	return parser.ExclusiveMin(v)
+}
+
+// Returns option of TemplateBuilder which sets exclusive upper bound
+// restriction
+func ExclusiveMax(v interface{}) PropOption {
+	return parser.ExclusiveMax(v)
+}
+
+// Returns option of TemplateBuilder which sets min and max length
+// restrictions of string values
+func Length(min, max int) PropOption {
+	return parser.Length(min, max)
+}
+
+// Returns option of TemplateBuilder which sets min and max amount of array
+// items restrictions
+func Items(min, max int) PropOption {
+	return parser.Items(min, max)
+}
+
+// Returns option of TemplateBuilder which sets unique array items restriction
+func UniqueItems() PropOption {
+	return parser.UniqueItems()
+}
+
+// Returns option of TemplateBuilder which marks property as unique among all
+// nodes of according node type (or with according label) within graph
+func Unique() PropOption {
+	return parser.Unique()
+}
+
+// Returns option of TemplateBuilder which sets min and max amount of map
+// entries restrictions
+func Entries(min, max int) PropOption {
+	return parser.Entries(min, max)
+}
+
+// Returns option of TemplateBuilder which sets restrictions of "inner" values
+// of nested arrays and maps; opts which aren't restrictions are ignored
+func Inner(opts ...PropOption) PropOption {
+	return parser.Inner(opts...)
+}
+
+// Creates and returns new TemplateBuilder which builds template from Go
+// code; result of building process may be used as Validator-interface
+func NewTemplate() *TemplateBuilder {
+	return parser.NewTemplate()
+}
+
+// Registers dt custom data type with name, so it may be used within templates
+// (including "inner" values of arrays and maps and keys of maps) which are
+// parsed or built after registration; returns nil at success and error if name
+// is incorrect, conflicts with built-in data type or is already registered
+//
+// WARNING: custom data types can't be unregistered and are shared by ALL
+// templates, so this func should be called once per data type (e.g. within
+// init-func of package)
+func RegisterType(name string, dt DataType) error {
+	return template.RegisterType(name, dt)
+}
+
+// Registers fn validator with name, so it may be referenced within
+// validators-restriction of templates which are parsed or built after
+// registration (for checks which can't be expressed by declarative
+// restrictions); returns nil at success and error if name is incorrect or
+// is already registered
+//
+// WARNING: validators can't be unregistered and are shared by ALL templates,
+// so this func should be called once per validator (e.g. within init-func of
+// package)
+func RegisterValidator(name string, fn ValidatorFunc) error {
+	return template.RegisterValidator(name, fn)
+}
+
 // Parses ALREADY opened template-file (or any another representation
 // of it implementing io.Reader-interface) and returns result
 // as Validator-interface value; if any error occurs doesn't interrupt
@@ -92,20 +351,30 @@ type (
 // parsing
 //
 // WARNING: template dont allow some graph design practices, as:
-//   - maps nesting (which should be handled by making a new node/edge that
-//     contains nested map etc.)
-//   - arrays nesting (for the same reason as above)
-//   - nesting maps within arrays and vise versa (for the same reason as above)
+//   - maps and arrays nesting is allowed ONLY in recursive notation of data
+//     type - "array<value type>" or "map<key type,value type>" (for example,
+//     "array<map<string,int>>")
 //   - map's and array's values (and keys in case of maps) can contain only the
-//     same PRIMITIVE (int, string, etc) data types (for reasons of go data
-//     types compatibility)
-//   - labels can't nest within each other (which may lead to endless data-nesting
-//     and implicit property- and connection-definitions which may cause hard-to-find
-//     definition conflicts)
+//     same data types (for reasons of go data types compatibility); map's keys
+//     can be only PRIMITIVE (int, string, etc) data types
+//   - labels can inherit each other, but inheritance can't be cyclic and
+//     unrelated labels of the same node (or label) can't define the same
+//     property differently
 func ParseTemplate(file io.Reader) (Validator, error) {
 	return parser.ParseTemplate(file)
 }
 
+// Parses template-file with root path within fsys file system and all
+// template-files included by it and returns result as Validator-interface;
+// if any error occurs doesn't interrupt parsing and then returns error which
+// contains all occured errors during parsing
+//
+// WARNING: the same limitations as for ParseTemplate are applied; in addition
+// type names can't be duplicated within different files
+func ParseTemplateFS(fsys fs.FS, root string) (Validator, error) {
+	return parser.ParseTemplateFS(fsys, root)
+}
+
 // Creates and returns new Node-interface value with typ type name
 // and props properties
 func NewNode(typ string, props map[string]interface{}) Node {
@@ -118,6 +387,13 @@ func NewEdge(typ string, props map[string]interface{}) Edge {
 	return validation.NewEdge(typ, props)
 }
 
+// Creates and returns new Edge-interface value with typ type name and
+// props properties, which connects its nodes symmetrically (Graph-interface
+// presents such edge in both directions)
+func NewUndirectedEdge(typ string, props map[string]interface{}) Edge {
+	return validation.NewUndirectedEdge(typ, props)
+}
+
 // Creates and returns new Triplet-interface with m as main node entity,
 // s as subject node entity and e as edge entity
 func NewTriplet(m, s Node, e Edge) Triplet {
@@ -167,6 +443,22 @@ func NewGraph(ns []Node, gr ...Triplet) Graph {
 	return validation.NewGraph(ns, gr...)
 }
 
+// Does the same as NewGraph, but identity of nodes and edges within
+// returned Graph-interface is based on values of key properties (see
+// Keys-type) instead of equality of ALL their properties, which may be
+// done by using:
+//
+//	graph := NewKeyedGraph(GraphKeys(validator), nil, tripletSlice...)
+func NewKeyedGraph(keys Keys, ns []Node, gr ...Triplet) Graph {
+	return validation.NewKeyedGraph(keys, ns, gr...)
+}
+
+// Returns identity keys of nodes and edges defined within vr template;
+// returns empty Keys if vr can't provide them
+func GraphKeys(vr Validator) Keys {
+	return validation.GraphKeys(vr)
+}
+
 // Validates v (which might implements Node-, Edge- or Triplet-interface)
 // underlying data using vr and returns true and nil on success; in cases
 // where underlying data dont implement interfaces enumerated above this
@@ -175,3 +467,34 @@ func NewGraph(ns []Node, gr ...Triplet) Graph {
 func Validate(vr Validator, v interface{}) (bool, error) {
 	return validation.Validate(vr, v)
 }
+
+// Validates v the same way as Validate, but doesn't stop at the first error -
+// walks through ALL nodes, edges and connections and returns true and nil on
+// success or *ValidationReport which contains found errors grouped by invalid
+// entities; limit caps amount of collected errors (non-positive limit means
+// there is no cap); if vr isn't parsed template (and doesn't implement
+// ValidateAll-method) works exactly as Validate
+func ValidateAll(vr Validator, v interface{}, limit int) (bool, error) {
+	return validation.ValidateAll(vr, v, limit)
+}
+
+// Returns copy of n node where absent properties are filled in by default
+// values defined within vr template; returns n itself if there is nothing
+// to fill in or vr can't provide default values
+func ApplyDefaults(vr Validator, n Node) Node {
+	return validation.ApplyDefaults(vr, n)
+}
+
+// Returns copy of e edge where absent properties are filled in by default
+// values defined within vr template; returns e itself if there is nothing
+// to fill in or vr can't provide default values
+func ApplyEdgeDefaults(vr Validator, e Edge) Edge {
+	return validation.ApplyEdgeDefaults(vr, e)
+}
+
+// Returns new Graph-interface value built from gr graph where absent
+// properties of all nodes and edges are filled in by default values defined
+// within vr template; returns gr itself if vr can't provide default values
+func ApplyGraphDefaults(vr Validator, gr Graph) Graph {
+	return validation.ApplyGraphDefaults(vr, gr)
+}
@@ -1,8 +1,11 @@
 package stg
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,6 +26,92 @@ func init() {
 	}
 
 	testTime, _ = time.Parse(time.RFC3339, "1111-11-11T11:11:11Z")
+
+	if err := RegisterType("semver", semVerType{}); err != nil {
+		panic(err)
+	}
+	if err := RegisterValidator("luhn", luhn); err != nil {
+		panic(err)
+	}
+	if err := RegisterValidator("stable", func(v interface{}) error {
+		if v.(semVer).Major == 0 {
+			return fmt.Errorf("major version is 0")
+		}
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Validator which checks string of digits using Luhn algorithm
+func luhn(v interface{}) error {
+	s, _ := v.(string)
+	sum := 0
+	for i := range s {
+		d := int(s[len(s)-1-i] - '0')
+		if d < 0 || d > 9 {
+			return fmt.Errorf("%q isn't digit", s[len(s)-1-i])
+		}
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if sum%10 != 0 {
+		return fmt.Errorf("checksum is wrong")
+	}
+	return nil
+}
+
+// Semantic version which is used as value of custom data type
+type semVer struct {
+	Major, Minor, Patch int
+}
+
+// Custom data type of semantic versions - accepts semVer-structs and
+// strings in "major.minor.patch" form
+type semVerType struct{}
+
+func (semVerType) Assert(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case semVer:
+		return v, true
+	case string:
+		res, err := semVerType{}.Parse(v)
+		return res, err == nil
+	}
+	return nil, false
+}
+
+func (semVerType) Parse(s string) (interface{}, error) {
+	var v semVer
+	if _, err := fmt.Sscanf(s, "%d.%d.%d", &v.Major, &v.Minor, &v.Patch); err != nil || v.String() != s {
+		return nil, fmt.Errorf("%q isn't semantic version", s)
+	}
+	return v, nil
+}
+
+func (semVerType) Compare(v1, v2 interface{}) int {
+	a, b := v1.(semVer), v2.(semVer)
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+func (semVerType) Format(v interface{}) string {
+	return v.(semVer).String()
+}
+
+func (v semVer) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 }
 
 func TestValidateNode(t *testing.T) {
@@ -44,6 +133,1666 @@ func TestValidateNode(t *testing.T) {
 	}
 }
 
+func TestValidateOptionalProperty(t *testing.T) {
+	props := map[string]interface{}{
+		"name":    "Jora",
+		"birth":   testTime,
+		"merried": true,
+		"age":     22.7,
+		"money":   34,
+		"things":  []string{"other thing"},
+		"adresses": map[string]string{
+			"street 1": "house  ",
+		},
+	}
+	if ok, err := Validate(templ, NewNode("Person", props)); !ok {
+		t.Error("Is NOT valid: Node-interface without optional property -> " + err.Error())
+	}
+	props["nickname"] = nil
+	if ok, err := Validate(templ, NewNode("Person", props)); !ok {
+		t.Error("Is NOT valid: Node-interface with nil nullable property -> " + err.Error())
+	}
+	props["nickname"] = "Jo"
+	if ok, err := Validate(templ, NewNode("Person", props)); !ok {
+		t.Error("Is NOT valid: Node-interface with optional property -> " + err.Error())
+	}
+	delete(props, "money")
+	if ok, _ := Validate(templ, NewNode("Person", props)); ok {
+		t.Error("Is valid: Node-interface without required property")
+	}
+	props["money"] = nil
+	if ok, _ := Validate(templ, NewNode("Person", props)); ok {
+		t.Error("Is valid: Node-interface with nil not nullable property")
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	person := NewNode("Person", map[string]interface{}{
+		"name":    "Jora",
+		"birth":   testTime,
+		"merried": true,
+		"age":     22.7,
+		"money":   34,
+		"things":  []string{"other thing"},
+		"adresses": map[string]string{
+			"street 1": "house  ",
+		},
+	})
+	res := ApplyDefaults(templ, person)
+	if v, ok := res.GetProp("nickname"); !ok || v != "Anonymous" {
+		t.Error("Default value is NOT applied: Node-interface")
+	}
+	if _, ok := person.GetProp("nickname"); ok {
+		t.Error("Default value is applied to the origin: Node-interface")
+	}
+	if ok, err := Validate(templ, res); !ok {
+		t.Error("Is NOT valid: Node-interface with default value -> " + err.Error())
+	}
+
+	friend := NewEdge("friend", map[string]interface{}{
+		"since": testTime,
+	})
+	gr := ApplyGraphDefaults(templ, NewGraph(nil, NewTriplet(person, person, friend)))
+	for _, n := range gr.GetNodes() {
+		if v, ok := n.GetProp("nickname"); !ok || v != "Anonymous" {
+			t.Error("Default value is NOT applied: Graph-interface")
+		}
+	}
+	if len(gr.GetTriplets()) != 1 {
+		t.Error("Graph-interface with default values has lost its edges")
+	}
+}
+
+func TestValidateRangeRestrictions(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      age:
+        type: int
+        restrictions:
+          min: 0
+          max: 150
+      birth:
+        type: datetime
+        restrictions:
+          exclusive_min: 1900-01-01T00:00:00Z
+      scores:
+        type: array-float
+        restrictions:
+          min: 0.0
+          exclusive_max: 10.0
+edges:
+  friend:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	props := map[string]interface{}{
+		"age":    150,
+		"birth":  testTime.AddDate(900, 0, 0),
+		"scores": []float64{0.0, 9.9},
+	}
+	if ok, err := Validate(vr, NewNode("Person", props)); !ok {
+		t.Error("Is NOT valid: Node-interface within ranges -> " + err.Error())
+	}
+	for k, v := range map[string]interface{}{
+		"age":    151,
+		"birth":  testTime,
+		"scores": []float64{0.0, 10.0},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[k] = v
+		if ok, _ := Validate(vr, NewNode("Person", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with %q property out of range", k)
+		}
+	}
+}
+
+func TestValidateSizeRestrictions(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      name:
+        type: string
+        restrictions:
+          min_length: 2
+          max_length: 4
+      tags:
+        type: array-string
+        restrictions:
+          min_items: 1
+          max_items: 3
+          unique_items: true
+          max_length: 5
+      adresses:
+        type: map-string-string
+        restrictions:
+          max_entries: 1
+edges:
+  friend:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	props := map[string]interface{}{
+		"name":     "Jora",
+		"tags":     []string{"a", "b"},
+		"adresses": map[string]string{},
+	}
+	if ok, err := Validate(vr, NewNode("Person", props)); !ok {
+		t.Error("Is NOT valid: Node-interface within sizes -> " + err.Error())
+	}
+	for k, v := range map[string]interface{}{
+		"name":     "Georgiy",
+		"tags":     []string{},
+		"adresses": map[string]string{"1": "1", "2": "2"},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[k] = v
+		if ok, _ := Validate(vr, NewNode("Person", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with %q property out of size", k)
+		}
+	}
+	for _, v := range [][]string{{"a", "b", "c", "d"}, {"a", "a"}, {"longtag"}} {
+		props["tags"] = v
+		if ok, _ := Validate(vr, NewNode("Person", props)); ok {
+			t.Errorf("Is valid: Node-interface with %v tags", v)
+		}
+	}
+	for _, v := range [][]interface{}{{"a", nil}, {nil, nil}} {
+		props["tags"] = v
+		if ok, _ := Validate(vr, NewNode("Person", props)); ok {
+			t.Errorf("Is valid: Node-interface with %v tags", v)
+		}
+	}
+}
+
+func TestValidateNestedProperty(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      scores:
+        type: array<map<string,array<int>>>
+        restrictions:
+          max_items: 2
+          inner:
+            key_regexps:
+              - ^[a-z]+$
+            inner:
+              min_items: 1
+              max: 10
+      ranks:
+        type: array<int>
+        required: false
+      marks:
+        type: map<string,int>
+        required: false
+edges:
+  friend:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	person := NewNode("Person", map[string]interface{}{
+		"scores": []map[string][]int{
+			{"math": {5, 10}},
+			{"art": {1}, "music": {7}},
+		},
+	})
+	if ok, err := Validate(vr, person); !ok {
+		t.Error("Is NOT valid: Node-interface with nested property -> " + err.Error())
+	}
+	for _, v := range [][]map[string][]int{
+		{{"math": {5}}, {"art": {5}}, {"music": {5}}},
+		{{"Math": {5}}},
+		{{"math": {}}},
+		{{"math": {11}}},
+	} {
+		person := NewNode("Person", map[string]interface{}{
+			"scores": v,
+		})
+		if ok, _ := Validate(vr, person); ok {
+			t.Errorf("Is valid: Node-interface with %v nested property", v)
+		}
+	}
+
+	scores := []map[string][]int{{"math": {5}}}
+	person = NewNode("Person", map[string]interface{}{
+		"scores": scores,
+		"ranks":  []interface{}{1, 2},
+		"marks":  map[string]interface{}{"a": 1, "b": 2},
+	})
+	if ok, err := Validate(vr, person); !ok {
+		t.Error("Is NOT valid: Node-interface with interface items -> " + err.Error())
+	}
+	for k, v := range map[string]interface{}{
+		"ranks": []interface{}{1, "two", 3.5},
+		"marks": map[string]interface{}{"a": 1, "b": "x"},
+	} {
+		person := NewNode("Person", map[string]interface{}{
+			"scores": scores,
+			k:        v,
+		})
+		if ok, _ := Validate(vr, person); ok {
+			t.Errorf("Is valid: Node-interface with mixed-type %q property", k)
+		}
+	}
+	person = NewNode("Person", map[string]interface{}{
+		"scores": scores,
+		"marks":  map[interface{}]int{"a": 1, 2: 2},
+	})
+	if ok, _ := Validate(vr, person); ok {
+		t.Error("Is valid: Node-interface with mixed-type keys of \"marks\" property")
+	}
+}
+
+func TestValidateNamedType(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+types:
+  Email:
+    type: string
+    restrictions:
+      regexps:
+        - ^[a-z]+@[a-z]+\.[a-z]+$
+nodes:
+  Person:
+    properties:
+      email:
+        type: Email
+  Company:
+    properties:
+      email:
+        type: Email
+        required: false
+edges:
+  friend:
+    properties:
+      email:
+        type: Email
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	valid := map[string]interface{}{"email": "jora@mail.com"}
+	invalid := map[string]interface{}{"email": "jora"}
+	if ok, err := Validate(vr, NewNode("Person", valid)); !ok {
+		t.Error("Is NOT valid: Node-interface with named type -> " + err.Error())
+	}
+	if ok, err := Validate(vr, NewEdge("friend", valid)); !ok {
+		t.Error("Is NOT valid: Edge-interface with named type -> " + err.Error())
+	}
+	if ok, err := Validate(vr, NewNode("Company", map[string]interface{}{})); !ok {
+		t.Error("Is NOT valid: Node-interface with optional named type -> " + err.Error())
+	}
+	if ok, _ := Validate(vr, NewNode("Person", invalid)); ok {
+		t.Error("Is valid: Node-interface with named type mismatch")
+	}
+	if ok, _ := Validate(vr, NewEdge("friend", invalid)); ok {
+		t.Error("Is valid: Edge-interface with named type mismatch")
+	}
+}
+
+func TestValidateBuiltTemplate(t *testing.T) {
+	vr, err := NewTemplate().
+		Type("Email", String, Regexps(`^.+@.+$`)).
+		Label("Creature").Prop("name", String, Length(1, 32)).
+		Node("Person", "Creature").
+		Prop("age", Int, Range(0, 150)).
+		Prop("email", "Email", Optional()).
+		Prop("tags", Array(String), Items(0, 3), UniqueItems()).
+		Prop("balance", Decimal(10, 2), Optional()).
+		Prop("id", UUID, Optional()).
+		Connect("Person", "friend", 0, -1).
+		Edge("friend").Prop("since", DateTime, Nullable()).
+		Build()
+	if err != nil {
+		t.Fatal("Template is NOT built -> " + err.Error())
+	}
+	person := NewNode("Person", map[string]interface{}{
+		"name": "Jora",
+		"age":  22,
+		"tags": []string{"a", "b"},
+	})
+	if ok, err := Validate(vr, person); !ok {
+		t.Error("Is NOT valid: Node-interface -> " + err.Error())
+	}
+	for k, v := range map[string]interface{}{
+		"age":     151,
+		"name":    "",
+		"email":   "jora",
+		"tags":    []string{"a", "a"},
+		"balance": "1.005",
+	} {
+		invalid := map[string]interface{}{
+			"name": "Jora",
+			"age":  22,
+			"tags": []string{"a", "b"},
+		}
+		invalid[k] = v
+		if ok, _ := Validate(vr, NewNode("Person", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with wrong %q property", k)
+		}
+	}
+	if ok, err := Validate(vr, NewEdge("friend", map[string]interface{}{"since": nil})); !ok {
+		t.Error("Is NOT valid: Edge-interface -> " + err.Error())
+	}
+}
+
+func TestValidateIncomingConnections(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      name:
+        type: string
+    connections:
+      Pet:
+        - edge: owns
+          ratio:
+            min: 0
+            max: -1
+          incoming:
+            min: 1
+            max: 1
+  Pet:
+    properties:
+      name:
+        type: string
+edges:
+  owns:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	p1 := NewNode("Person", map[string]interface{}{"name": "Jora"})
+	p2 := NewNode("Person", map[string]interface{}{"name": "Vasya"})
+	pet := NewNode("Pet", map[string]interface{}{"name": "Bobik"})
+	owns := NewEdge("owns", map[string]interface{}{})
+
+	gr := NewGraph(nil, NewTriplet(p1, pet, owns))
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with single owner -> " + err.Error())
+	}
+	gr = NewGraph([]Node{p1, pet})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph without owner")
+	}
+	gr = NewGraph(nil, NewTriplet(p1, pet, owns), NewTriplet(p2, pet, owns))
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with 2 owners")
+	}
+
+	// parents of graph without GetNodeParents-method are derived from triplets
+	if ok, err := Validate(vr, plainGraph{NewGraph(nil, NewTriplet(p1, pet, owns))}); !ok {
+		t.Error("Is NOT valid: plain graph with single owner -> " + err.Error())
+	}
+	if ok, _ := Validate(vr, plainGraph{gr}); ok {
+		t.Error("Is valid: plain graph with 2 owners")
+	}
+}
+
+// Graph-interface implementation which doesn't implement any of optional
+// extensions of graph
+type plainGraph struct {
+	Graph
+}
+
+func TestValidateUndirectedConnections(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      name:
+        type: string
+    connections:
+      Person:
+        - edge: friend
+          ratio:
+            min: 1
+            max: -1
+        - edge: follows
+          ratio:
+            min: 0
+            max: -1
+          bidirectional: true
+edges:
+  friend:
+    direction: undirected
+  follows:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	p1 := NewNode("Person", map[string]interface{}{"name": "Jora"})
+	p2 := NewNode("Person", map[string]interface{}{"name": "Vasya"})
+	friend := NewEdge("friend", map[string]interface{}{})
+	follows := NewEdge("follows", map[string]interface{}{})
+
+	gr := NewGraph(nil, NewTriplet(p1, p2, friend))
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with single undirected edge -> " + err.Error())
+	}
+	gr = NewGraph(nil, NewTriplet(p1, p2, friend), NewTriplet(p1, p2, follows), NewTriplet(p2, p1, follows))
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with bidirectional edges -> " + err.Error())
+	}
+	gr = NewGraph(nil, NewTriplet(p1, p2, friend), NewTriplet(p1, p2, follows))
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph without reverse bidirectional edge")
+	}
+	if ok, err := Validate(vr, NewTriplet(p2, p1, friend)); !ok {
+		t.Error("Is NOT valid: reverse triplet with undirected edge -> " + err.Error())
+	}
+
+	uFriend := NewUndirectedEdge("friend", map[string]interface{}{})
+	gr = NewGraph(nil, NewTriplet(p1, p2, uFriend), NewTriplet(p2, p1, uFriend))
+	if len(gr.GetNodeChilds(p2)) != 1 || len(gr.GetTriplets()) != 2 {
+		t.Error("Undirected edge is NOT presented symmetrically within graph")
+	}
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with undirected graph edge -> " + err.Error())
+	}
+
+	// undirected graph edge doesn't satisfy directed connection in reverse
+	vr, err = ParseTemplate(strings.NewReader(`
+nodes:
+  A:
+    connections:
+      B:
+        - edge: e
+          ratio:
+            min: 0
+            max: -1
+  B:
+edges:
+  e:
+    acyclic: true
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	a, b := NewNode("A", map[string]interface{}{}), NewNode("B", map[string]interface{}{})
+	uE := NewUndirectedEdge("e", map[string]interface{}{})
+	if ok, _ := Validate(vr, NewTriplet(b, a, uE)); ok {
+		t.Error("Is valid: reverse triplet with undirected graph edge of directed edge type")
+	}
+	if ok, _ := Validate(vr, NewTriplet(a, b, uE)); ok {
+		t.Error("Is valid: triplet with undirected graph edge of directed edge type")
+	}
+	gr = NewGraph(nil, NewTriplet(a, b, uE))
+	if ok, err := ValidateAll(vr, gr, 0); ok {
+		t.Error("Is valid: graph with undirected graph edge of directed edge type")
+	} else if strings.Contains(err.Error(), "cycle") {
+		t.Error("Wrong error: " + err.Error())
+	}
+}
+
+func TestValidateConnectionEdgeProperties(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    properties:
+      name:
+        type: string
+    connections:
+      Org:
+        - edge: member_of
+          ratio:
+            min: 0
+            max: -1
+          properties:
+            role:
+              type: string
+              restrictions:
+                values:
+                  - admin
+                  - user
+      Project:
+        - edge: member_of
+          ratio:
+            min: 0
+            max: -1
+          properties:
+            role:
+              type: string
+              restrictions:
+                values:
+                  - owner
+                  - viewer
+            since:
+              type: int
+  Org:
+  Project:
+edges:
+  member_of:
+    properties:
+      role:
+        type: string
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	p := NewNode("Person", map[string]interface{}{"name": "Jora"})
+	org := NewNode("Org", map[string]interface{}{})
+	prj := NewNode("Project", map[string]interface{}{})
+	admin := NewEdge("member_of", map[string]interface{}{"role": "admin"})
+	owner := NewEdge("member_of", map[string]interface{}{"role": "owner", "since": 2020})
+
+	if ok, err := Validate(vr, NewTriplet(p, org, admin)); !ok {
+		t.Error("Is NOT valid: Person-Org triplet with admin role -> " + err.Error())
+	}
+	if ok, _ := Validate(vr, NewTriplet(p, prj, admin)); ok {
+		t.Error("Is valid: Person-Project triplet with admin role")
+	}
+	if ok, _ := Validate(vr, NewTriplet(p, org, owner)); ok {
+		t.Error("Is valid: Person-Org triplet with owner role")
+	}
+	gr := NewGraph(nil, NewTriplet(p, org, admin), NewTriplet(p, prj, owner))
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with connection-specific edges -> " + err.Error())
+	}
+	gr = NewGraph(nil, NewTriplet(p, prj, NewEdge("member_of", map[string]interface{}{"role": "owner"})))
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with edge without connection-specific property")
+	}
+}
+
+func TestValidateUniqueProperties(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+labels:
+  Account:
+    properties:
+      login:
+        type: string
+        unique: true
+nodes:
+  Person:
+    labels:
+      - Account
+    unique_together:
+      - [first, last]
+    properties:
+      email:
+        type: string
+        unique: true
+      first:
+        type: string
+      last:
+        type: string
+        required: false
+  Bot:
+    labels:
+      - Account
+edges:
+  knows:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	person := func(email, login, first, last string) Node {
+		props := map[string]interface{}{"email": email, "login": login, "first": first}
+		if last != "" {
+			props["last"] = last
+		}
+		return NewNode("Person", props)
+	}
+
+	gr := NewGraph([]Node{person("a@a", "a", "Jora", "Ivanov"), person("b@b", "b", "Jora", "")})
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with unique values -> " + err.Error())
+	}
+	gr = NewGraph([]Node{person("a@a", "a", "Jora", ""), person("a@a", "b", "Vasya", "")})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with duplicate email")
+	}
+	gr = NewGraph([]Node{person("a@a", "a", "Jora", "Ivanov"), person("b@b", "b", "Jora", "Ivanov")})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with duplicate first and last names")
+	}
+	gr = NewGraph([]Node{person("a@a", "a", "Jora", ""), NewNode("Bot", map[string]interface{}{"login": "a"})})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with duplicate login of different node types")
+	}
+
+	// values are compared in canonical form
+	vr, err = ParseTemplate(strings.NewReader(`
+nodes:
+  Device:
+    properties:
+      id:
+        type: uuid
+        unique: true
+      price:
+        type: decimal
+        required: false
+        unique: true
+edges:
+  knows:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	device := func(id string, price interface{}) Node {
+		props := map[string]interface{}{"id": id}
+		if price != nil {
+			props["price"] = price
+		}
+		return NewNode("Device", props)
+	}
+	gr = NewGraph([]Node{device("7e57d004-2b97-0e7a-b45f-5387367791cd", "1.5"), device("7E57D004-2B97-0E7A-B45F-5387367791CE", "1.50")})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with duplicate decimal prices")
+	}
+	gr = NewGraph([]Node{device("7e57d004-2b97-0e7a-b45f-5387367791cd", nil), device("7E57D004-2B97-0E7A-B45F-5387367791CD", nil)})
+	if ok, _ := Validate(vr, gr); ok {
+		t.Error("Is valid: graph with duplicate uuids in different letter case")
+	}
+	gr = NewGraph([]Node{device("7e57d004-2b97-0e7a-b45f-5387367791cd", "1.5"), device("7e57d004-2b97-0e7a-b45f-5387367791ce", "2")})
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: graph with unique uuids and prices -> " + err.Error())
+	}
+}
+
+func TestValidateConstraints(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+labels:
+  Priced:
+    constraints:
+      - discount <= price
+    properties:
+      price:
+        type: float
+      discount:
+        type: float
+        required: false
+nodes:
+  Order:
+    labels:
+      - Priced
+    constraints:
+      - len(items) == count && count > 0
+    properties:
+      items:
+        type: array-string
+      count:
+        type: int
+  Event:
+    constraints:
+      - end > start
+    properties:
+      start:
+        type: datetime
+      end:
+        type: datetime
+edges:
+  contains:
+    constraints:
+      - amount * 2 >= 1
+    properties:
+      amount:
+        type: int
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	order := func(items []string, count int, price, discount float64) Node {
+		return NewNode("Order", map[string]interface{}{"items": items, "count": count, "price": price, "discount": discount})
+	}
+	now := time.Now()
+
+	sucs := map[string]interface{}{
+		"order":                     order([]string{"a", "b"}, 2, 10, 5),
+		"order without discount":    NewNode("Order", map[string]interface{}{"items": []string{"a"}, "count": 1, "price": 10.0}),
+		"event":                     NewNode("Event", map[string]interface{}{"start": now, "end": now.Add(time.Hour)}),
+		"edge with positive amount": NewEdge("contains", map[string]interface{}{"amount": 1}),
+	}
+	for k, v := range sucs {
+		if ok, err := Validate(vr, v); !ok {
+			t.Error("Is NOT valid: " + k + " -> " + err.Error())
+		}
+	}
+	fails := map[string]interface{}{
+		"order with wrong count":        order([]string{"a", "b"}, 3, 10, 5),
+		"empty order":                   order([]string{}, 0, 10, 5),
+		"order with excessive discount": order([]string{"a"}, 1, 10, 15),
+		"event which ends before start": NewNode("Event", map[string]interface{}{"start": now, "end": now.Add(-time.Hour)}),
+		"edge with non-positive amount": NewEdge("contains", map[string]interface{}{"amount": 0}),
+	}
+	for k, v := range fails {
+		if ok, _ := Validate(vr, v); ok {
+			t.Error("Is valid: " + k)
+		}
+	}
+
+	_, err = Validate(vr, order([]string{"a"}, 1, 10, 15))
+	if err == nil || !strings.Contains(err.Error(), "discount <= price") {
+		t.Error("Error doesn't contain failing constraint")
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Order:
+    when:
+      - if:
+          status: shipped
+        then:
+          required: [shipped_at, tracking]
+          properties:
+            tracking:
+              restrictions:
+                regexps: ["^[A-Z0-9]+$"]
+    properties:
+      status:
+        type: string
+      shipped_at:
+        type: datetime
+        required: false
+      tracking:
+        type: string
+        required: false
+edges:
+  pays:
+    when:
+      - if:
+          method: card
+          verified: true
+        then:
+          properties:
+            sum:
+              restrictions:
+                max: 1000
+    properties:
+      method:
+        type: string
+      verified:
+        type: bool
+      sum:
+        type: int
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	order := func(props map[string]interface{}) Node {
+		return NewNode("Order", props)
+	}
+	pays := func(method string, verified bool, sum int) Edge {
+		return NewEdge("pays", map[string]interface{}{"method": method, "verified": verified, "sum": sum})
+	}
+
+	sucs := map[string]interface{}{
+		"new order":                   order(map[string]interface{}{"status": "new"}),
+		"new order with tracking":     order(map[string]interface{}{"status": "new", "tracking": "lowercase"}),
+		"shipped order":               order(map[string]interface{}{"status": "shipped", "shipped_at": time.Now(), "tracking": "AB12"}),
+		"cash payment":                pays("cash", true, 5000),
+		"unverified card payment":     pays("card", false, 5000),
+		"small verified card payment": pays("card", true, 100),
+	}
+	for k, v := range sucs {
+		if ok, err := Validate(vr, v); !ok {
+			t.Error("Is NOT valid: " + k + " -> " + err.Error())
+		}
+	}
+	fails := map[string]interface{}{
+		"shipped order without tracking":    order(map[string]interface{}{"status": "shipped", "shipped_at": time.Now()}),
+		"shipped order with wrong tracking": order(map[string]interface{}{"status": "shipped", "shipped_at": time.Now(), "tracking": "ab12"}),
+		"big verified card payment":         pays("card", true, 5000),
+	}
+	for k, v := range fails {
+		if ok, _ := Validate(vr, v); ok {
+			t.Error("Is valid: " + k)
+		}
+	}
+
+	_, err = Validate(vr, order(map[string]interface{}{"status": "shipped"}))
+	exp := `"Order"-node: condition (status == "shipped"): validated entity doesn't has "shipped_at", "tracking" properties`
+	if err == nil || err.Error() != exp {
+		t.Error("Wrong error of unsatisfied condition")
+	}
+}
+
+func TestValidateStructure(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Employee:
+    key: [id]
+    properties:
+      id:
+        type: int
+    connections:
+      Employee:
+        - edge: reports_to
+          ratio:
+            min: 0
+            max: -1
+  Task:
+    key: [id]
+    properties:
+      id:
+        type: int
+    connections:
+      Task:
+        - edge: depends_on
+          ratio:
+            min: 0
+            max: -1
+edges:
+  reports_to:
+    tree: true
+    max_depth: 2
+  depends_on:
+    acyclic: true
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	emp := func(id int) Node {
+		return NewNode("Employee", map[string]interface{}{"id": id})
+	}
+	task := func(id int) Node {
+		return NewNode("Task", map[string]interface{}{"id": id})
+	}
+	reports := func(m, s int) Triplet {
+		return NewTriplet(emp(m), emp(s), NewEdge("reports_to", nil))
+	}
+	depends := func(m, s int) Triplet {
+		return NewTriplet(task(m), task(s), NewEdge("depends_on", nil))
+	}
+
+	sucs := map[string]Graph{
+		"org chart":  NewGraph(nil, reports(2, 1), reports(3, 1), reports(4, 2)),
+		"build dag":  NewGraph(nil, depends(1, 2), depends(1, 3), depends(2, 4), depends(3, 4)),
+		"single ceo": NewGraph([]Node{emp(1)}),
+	}
+	for k, v := range sucs {
+		if ok, err := Validate(vr, v); !ok {
+			t.Error("Is NOT valid: " + k + " -> " + err.Error())
+		}
+	}
+
+	fails := map[string]struct {
+		gr  Graph
+		err string
+	}{
+		"cyclic build graph": {
+			NewGraph(nil, depends(1, 2), depends(2, 3), depends(3, 1)),
+			`Graph: "depends_on"-edge: has cycle Task{id: 1} -> Task{id: 2} -> Task{id: 3} -> Task{id: 1}`,
+		},
+		"two ceos": {
+			NewGraph([]Node{emp(5)}, reports(2, 1), reports(3, 1)),
+			`Graph: "reports_to"-edge: has disconnected component of nodes: Employee{id: 5}`,
+		},
+		"two managers": {
+			NewGraph(nil, reports(2, 1), reports(3, 1), reports(4, 2), reports(4, 3)),
+			`Graph: "reports_to"-edge: Employee{id: 4} has several parents within tree: Employee{id: 2}, Employee{id: 3}`,
+		},
+		"deep org chart": {
+			NewGraph(nil, reports(2, 1), reports(3, 2), reports(4, 3)),
+			`Graph: "reports_to"-edge: has path Employee{id: 4} -> Employee{id: 3} -> Employee{id: 2} -> Employee{id: 1} which is longer than 2 maximum depth`,
+		},
+	}
+	for k, v := range fails {
+		ok, err := Validate(vr, v.gr)
+		if ok {
+			t.Error("Is valid: " + k)
+			continue
+		}
+		if err.Error() != v.err {
+			t.Error("Wrong error: " + k + " -> " + err.Error())
+		}
+	}
+}
+
+func TestValidatePolymorphicConnections(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+labels:
+  Owner:
+    connections:
+      Animal:
+        - edge: owns
+          ratio:
+            min: 1
+            max: 3
+          incoming:
+            min: 0
+            max: 1
+          polymorphic: true
+  Animal:
+nodes:
+  Human:
+    labels:
+      - Owner
+    key: [name]
+    properties:
+      name:
+        type: string
+  Shelter:
+    labels:
+      - Owner
+    key: [name]
+    properties:
+      name:
+        type: string
+  Dog:
+    labels:
+      - Animal
+    key: [name]
+    properties:
+      name:
+        type: string
+  Cat:
+    labels:
+      - Animal
+    key: [name]
+    properties:
+      name:
+        type: string
+edges:
+  owns:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	node := func(typ, name string) Node {
+		return NewNode(typ, map[string]interface{}{"name": name})
+	}
+	owns := func(m, s Node) Triplet {
+		return NewTriplet(m, s, NewEdge("owns", nil))
+	}
+	human, shelter := node("Human", "Jora"), node("Shelter", "Home")
+
+	gr := NewGraph(nil, owns(human, node("Dog", "Rex")), owns(human, node("Dog", "Max")), owns(human, node("Cat", "Tom")))
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: human with 3 animals -> " + err.Error())
+	}
+
+	gr = NewGraph(nil, owns(human, node("Dog", "Rex")), owns(human, node("Dog", "Max")),
+		owns(human, node("Cat", "Tom")), owns(human, node("Cat", "Kitty")))
+	exp := `Graph: "Human": has 4 connection through "owns"-edge to "Animal"-label nodes, which is larger then 3 maximum of "Owner"-"Animal" label connection`
+	if ok, err := Validate(vr, gr); ok {
+		t.Error("Is valid: human with 4 animals")
+	} else if err.Error() != exp {
+		t.Error("Wrong error: human with 4 animals -> " + err.Error())
+	}
+
+	gr = NewGraph(nil, owns(human, node("Dog", "Rex")), owns(shelter, node("Dog", "Rex")))
+	exp = `Graph: "Dog": has 2 incoming connection through "owns"-edge from "Owner"-label nodes, which is larger then 1 maximum of "Owner"-"Animal" label connection`
+	if ok, err := Validate(vr, gr); ok {
+		t.Error("Is valid: dog with 2 owners")
+	} else if err.Error() != exp {
+		t.Error("Wrong error: dog with 2 owners -> " + err.Error())
+	}
+
+	gr = NewGraph([]Node{human, node("Dog", "Rex")})
+	exp = `Graph: "Human": has 0 connection through "owns"-edge to "Animal"-label nodes, which is less then 1 minimum of "Owner"-"Animal" label connection`
+	if ok, err := Validate(vr, gr); ok {
+		t.Error("Is valid: human without animals")
+	} else if err.Error() != exp {
+		t.Error("Wrong error: human without animals -> " + err.Error())
+	}
+}
+
+func TestValidateDateTimeFormat(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+settings:
+  datetime:
+    layout: 2006-01-02 15:04
+    timezone: "+02:00"
+nodes:
+  Shift:
+    properties:
+      start:
+        type: datetime
+        restrictions:
+          regexps: [" 0[89]:", " 1[0-7]:"]
+          min: 2023-01-01 00:00
+      delta:
+        type: int
+        restrictions:
+          values: [-1, +1]
+      rate:
+        type: float
+        restrictions:
+          exclusive_min: -1e-3
+edges:
+  follows:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	props := map[string]interface{}{
+		"start": time.Date(2023, 1, 2, 7, 30, 0, 0, time.UTC),
+		"delta": -1,
+		"rate":  -0.0005,
+	}
+	if ok, err := Validate(vr, NewNode("Shift", props)); !ok {
+		t.Error("Is NOT valid: Node-interface within working hours -> " + err.Error())
+	}
+	for _, v := range []struct {
+		k   string
+		v   interface{}
+		err string
+	}{
+		{"start", time.Date(2023, 1, 2, 5, 0, 0, 0, time.UTC), `"Shift"-node: "start"-property: "2023-01-02 07:00" value doesn't match neither restrictions`},
+		{"start", time.Date(2022, 12, 31, 20, 0, 0, 0, time.UTC), `"Shift"-node: "start"-property: "2022-12-31 22:00" value doesn't satisfy "min" restriction "2023-01-01 00:00"`},
+		{"rate", -1e-2, `"Shift"-node: "rate"-property: "-0.010000" value doesn't satisfy "exclusive min" restriction "-0.001"`},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[v.k] = v.v
+		if ok, err := Validate(vr, NewNode("Shift", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with wrong %q property", v.k)
+		} else if err.Error() != v.err {
+			t.Error("Wrong error: " + err.Error())
+		}
+	}
+}
+
+func TestValidateExtendedTypes(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Account:
+    properties:
+      id:
+        type: int64
+        restrictions:
+          min: 1
+      balance:
+        type: decimal(10,2)
+        restrictions:
+          min: -100
+      timeout:
+        type: duration
+        restrictions:
+          max: 1h
+      opened:
+        type: date
+        restrictions:
+          regexps: ["^202[0-9]-"]
+      closed:
+        type: date
+        required: false
+      token:
+        type: uuid
+      avatar:
+        type: bytes
+        restrictions:
+          max_length: 4
+      flags:
+        type: array-uint
+        restrictions:
+          max: 10
+      limits:
+        type: map<string,decimal(5,1)>
+    constraints:
+      - closed > opened
+edges:
+  transfer:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	type accountID int64
+	props := map[string]interface{}{
+		"id":      accountID(5),
+		"balance": "-12.50",
+		"timeout": 30 * time.Minute,
+		"opened":  time.Date(2023, 1, 1, 0, 0, 0, 0, time.FixedZone("", 3*60*60)),
+		"closed":  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		"token":   "3F2504E0-4F89-11D3-9A0C-0305E82C3301",
+		"avatar":  []byte{1, 2, 3},
+		"flags":   []uint{1, 10},
+		"limits":  map[string]string{"daily": "1000.5"},
+	}
+	if ok, err := Validate(vr, NewNode("Account", props)); !ok {
+		t.Error("Is NOT valid: Node-interface with extended data types -> " + err.Error())
+	}
+	for _, v := range []struct {
+		k   string
+		v   interface{}
+		err string
+	}{
+		{"id", int64(0), `"Account"-node: "id"-property: "0" value doesn't satisfy "min" restriction "1"`},
+		{"id", "5", `"Account"-node: "id"-property: "5" value doesn't match "int64" data type`},
+		{"balance", "123456789.5", `"Account"-node: "balance"-property: "123456789.5" value doesn't fit "decimal(10,2)" data type`},
+		{"balance", "1.555", `"Account"-node: "balance"-property: "1.555" value doesn't fit "decimal(10,2)" data type`},
+		{"balance", "-100.01", `"Account"-node: "balance"-property: "-100.01" value doesn't satisfy "min" restriction "-100"`},
+		{"timeout", 2 * time.Hour, `"Account"-node: "timeout"-property: "2h0m0s" value doesn't satisfy "max" restriction "1h0m0s"`},
+		{"timeout", int64(time.Minute), `"Account"-node: "timeout"-property: "60000000000" value doesn't match "duration" data type`},
+		{"opened", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), `"Account"-node: "opened"-property: "2019-01-01" value doesn't match neither restrictions`},
+		{"opened", time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), `"Account"-node: "opened"-property: "2023-01-01 12:00:00 +0000 UTC" value doesn't match "date" data type`},
+		{"closed", time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC), `"Account"-node: constraint "closed > opened" isn't satisfied`},
+		{"token", "3F2504E0", `"Account"-node: "token"-property: "3F2504E0" value doesn't match "uuid" data type`},
+		{"avatar", []byte{1, 2, 3, 4, 5}, `"Account"-node: "avatar"-property: "AQIDBAU=" value doesn't satisfy "max length" restriction "4"`},
+		{"flags", []uint{1, 11}, `"Account"-node: "flags"-property: "11" value doesn't satisfy "max" restriction "10"`},
+		{"limits", map[string]string{"daily": "1000.55"}, `"Account"-node: "limits"-property: "1000.55" value doesn't fit "decimal(5,1)" data type`},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[v.k] = v.v
+		if ok, err := Validate(vr, NewNode("Account", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with wrong %q property", v.k)
+		} else if err.Error() != v.err {
+			t.Error("Wrong error: " + err.Error())
+		}
+	}
+}
+
+func TestValidateCustomTypes(t *testing.T) {
+	if err := RegisterType("semver", semVerType{}); err == nil {
+		t.Error("Custom data type is registered twice")
+	}
+	if err := RegisterType("int", semVerType{}); err == nil {
+		t.Error("Custom data type conflicting with built-in one is registered")
+	}
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Package:
+    properties:
+      name:
+        type: string
+      version:
+        type: semver
+        restrictions:
+          min: 1.0.0
+          exclusive_max: 2.0.0
+      minimal:
+        type: semver
+        required: false
+        default: 1.0.0
+      history:
+        type: array-semver
+        restrictions:
+          regexps: ["^[01]\\."]
+      deps:
+        type: map<string,semver>
+        restrictions:
+          values: [1.2.0, 1.3.0]
+      released:
+        type: map-semver-datetime
+    constraints:
+      - version >= minimal
+    when:
+      - if:
+          version: 1.9.9
+        then:
+          required: [minimal]
+edges:
+  depends:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	props := map[string]interface{}{
+		"name":     "stg",
+		"version":  semVer{1, 4, 0},
+		"minimal":  "1.1.0",
+		"history":  []interface{}{"0.9.0", semVer{1, 0, 0}},
+		"deps":     map[string]semVer{"yaml": {1, 3, 0}},
+		"released": map[semVer]time.Time{{1, 4, 0}: testTime},
+	}
+	if ok, err := Validate(vr, NewNode("Package", props)); !ok {
+		t.Error("Is NOT valid: Node-interface with custom data types -> " + err.Error())
+	}
+	if v, _ := ApplyDefaults(vr, NewNode("Package", map[string]interface{}{})).GetProp("minimal"); v != (semVer{1, 0, 0}) {
+		t.Errorf("Wrong default value of custom data type: %v", v)
+	}
+	for _, v := range []struct {
+		k   string
+		v   interface{}
+		err string
+	}{
+		{"version", "2.0.0", `"Package"-node: "version"-property: "2.0.0" value doesn't satisfy "exclusive max" restriction "2.0.0"`},
+		{"version", 1.4, `"Package"-node: "version"-property: "1.4" value doesn't match "semver" data type`},
+		{"minimal", semVer{1, 5, 0}, `"Package"-node: constraint "version >= minimal" isn't satisfied`},
+		{"history", []string{"2.0.0"}, `"Package"-node: "history"-property: "2.0.0" value doesn't match neither restrictions`},
+		{"deps", map[string]string{"yaml": "1.1.0"}, `"Package"-node: "deps"-property: "1.1.0" value doesn't match neither restrictions`},
+		{"released", map[string]time.Time{"v1": testTime}, `"Package"-node: "released"-property: "map[v1:1111-11-11 11:11:11 +0000 UTC]" value doesn't match "map" data type`},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[v.k] = v.v
+		if ok, err := Validate(vr, NewNode("Package", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with wrong %q property", v.k)
+		} else if err.Error() != v.err {
+			t.Error("Wrong error: " + err.Error())
+		}
+	}
+	delete(props, "minimal")
+	props["version"] = "1.9.9"
+	if ok, err := Validate(vr, NewNode("Package", props)); ok {
+		t.Error("Is valid: Node-interface which doesn't satisfy condition with custom data type")
+	} else if err.Error() != `"Package"-node: condition (version == 1.9.9): validated entity doesn't has "minimal" properties` {
+		t.Error("Wrong error: " + err.Error())
+	}
+
+	type Package struct {
+		Name     string
+		Version  semVer
+		History  []semVer
+		Deps     map[string]semVer
+		Released map[semVer]time.Time
+	}
+	pkg := Package{
+		Name:     "stg",
+		Version:  semVer{1, 0, 1},
+		History:  []semVer{{0, 1, 0}},
+		Deps:     map[string]semVer{"yaml": {1, 2, 0}},
+		Released: map[semVer]time.Time{},
+	}
+	if ok, err := Validate(vr, pkg); !ok {
+		t.Error("Is NOT valid: Package-struct with custom data types -> " + err.Error())
+	}
+	pkg.Version = semVer{0, 9, 0}
+	if ok, err := Validate(vr, pkg); ok {
+		t.Error("Is valid: Package-struct with wrong custom data type property")
+	} else if !strings.Contains(err.Error(), `as node - "version"-property: "0.9.0" value doesn't satisfy "min" restriction "1.0.0"`) {
+		t.Error("Wrong error: " + err.Error())
+	}
+}
+
+func TestValidateValidators(t *testing.T) {
+	if err := RegisterValidator("luhn", luhn); err == nil {
+		t.Error("Validator is registered twice")
+	}
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Card:
+    properties:
+      number:
+        type: string
+        restrictions:
+          validators: [luhn]
+          max_length: 19
+      backups:
+        type: array<array<string>>
+        required: false
+        restrictions:
+          inner:
+            validators: [luhn]
+      firmware:
+        type: semver
+        restrictions:
+          validators: [stable]
+edges:
+  linked:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	props := map[string]interface{}{
+		"number":   "79927398713",
+		"backups":  [][]string{{"4539578763621486"}},
+		"firmware": "1.0.0",
+	}
+	if ok, err := Validate(vr, NewNode("Card", props)); !ok {
+		t.Error("Is NOT valid: Node-interface with validators -> " + err.Error())
+	}
+	for _, v := range []struct {
+		k   string
+		v   interface{}
+		err string
+	}{
+		{"number", "79927398710", `"Card"-node: "number"-property: "79927398710" value doesn't satisfy "luhn" validator: checksum is wrong`},
+		{"number", "7992739871x", `"Card"-node: "number"-property: "7992739871x" value doesn't satisfy "luhn" validator: 'x' isn't digit`},
+		{"backups", [][]string{{"4539578763621487"}}, `"Card"-node: "backups"-property: "4539578763621487" value doesn't satisfy "luhn" validator: checksum is wrong`},
+		{"firmware", semVer{0, 9, 0}, `"Card"-node: "firmware"-property: "0.9.0" value doesn't satisfy "stable" validator: major version is 0`},
+	} {
+		invalid := make(map[string]interface{})
+		for k, v := range props {
+			invalid[k] = v
+		}
+		invalid[v.k] = v.v
+		if ok, err := Validate(vr, NewNode("Card", invalid)); ok {
+			t.Errorf("Is valid: Node-interface with wrong %q property", v.k)
+		} else if err.Error() != v.err {
+			t.Error("Wrong error: " + err.Error())
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Account:
+    constraints:
+      - balance >= 0
+    when:
+      - if:
+          status: active
+        then:
+          required: [email]
+    properties:
+      status:
+        type: string
+        restrictions:
+          values: [active, blocked]
+      balance:
+        type: int
+      email:
+        type: string
+        required: false
+      tags:
+        type: array<string>
+        required: false
+        restrictions:
+          max_length: 3
+      limits:
+        type: map<string,int>
+        required: false
+        restrictions:
+          min: 0
+    connections:
+      Account:
+        - edge: trusts
+          ratio:
+            min: 0
+            max: 1
+edges:
+  trusts:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	account := func(props map[string]interface{}) Node {
+		res := map[string]interface{}{"status": "blocked", "balance": 0}
+		for k, v := range props {
+			res[k] = v
+		}
+		return NewNode("Account", res)
+	}
+	a1 := account(map[string]interface{}{"balance": 1})
+	a2 := account(map[string]interface{}{"balance": 2})
+	a3 := account(map[string]interface{}{"balance": 3})
+	trusts := NewEdge("trusts", map[string]interface{}{})
+
+	for k, v := range map[string]struct {
+		v   interface{}
+		err ValidationError
+	}{
+		"unknown type": {
+			NewNode("User", map[string]interface{}{}),
+			ValidationError{Code: ErrUnknownType, EntityType: "User"},
+		},
+		"extra property": {
+			account(map[string]interface{}{"age": 5}),
+			ValidationError{Code: ErrExtraProperty, EntityType: "Account", Property: "age", Path: "age"},
+		},
+		"missing property": {
+			NewNode("Account", map[string]interface{}{"status": "blocked"}),
+			ValidationError{Code: ErrMissingProperty, EntityType: "Account", Property: "balance", Path: "balance"},
+		},
+		"wrong data type": {
+			account(map[string]interface{}{"balance": "zero"}),
+			ValidationError{Code: ErrDataType, EntityType: "Account", Property: "balance", Path: "balance", Value: "zero"},
+		},
+		"wrong value": {
+			account(map[string]interface{}{"status": "deleted"}),
+			ValidationError{Code: ErrRestriction, EntityType: "Account", Property: "status", Path: "status", Value: "deleted", Restriction: "value"},
+		},
+		"wrong array item": {
+			account(map[string]interface{}{"tags": []string{"a", "long"}}),
+			ValidationError{Code: ErrRestriction, EntityType: "Account", Property: "tags", Path: "tags[1]", Value: "long", Restriction: "max length"},
+		},
+		"wrong map entry": {
+			account(map[string]interface{}{"limits": map[string]int{"daily": -1}}),
+			ValidationError{Code: ErrRestriction, EntityType: "Account", Property: "limits", Path: "limits[daily]", Value: -1, Restriction: "min"},
+		},
+		"unmet condition": {
+			account(map[string]interface{}{"status": "active"}),
+			ValidationError{Code: ErrCondition, EntityType: "Account", Property: "email", Path: "email"},
+		},
+		"unsatisfied constraint": {
+			account(map[string]interface{}{"balance": -1}),
+			ValidationError{Code: ErrConstraint, EntityType: "Account", Restriction: "balance >= 0"},
+		},
+		"excessive connections": {
+			NewGraph(nil, NewTriplet(a1, a2, trusts), NewTriplet(a1, a3, trusts)),
+			ValidationError{Code: ErrCardinality, EntityType: "Account", Value: 2, Restriction: "max"},
+		},
+	} {
+		_, err := Validate(vr, v.v)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("Error is NOT ValidationError: %s -> %v", k, err)
+			continue
+		}
+		got := ValidationError{
+			Code:        verr.Code,
+			EntityType:  verr.EntityType,
+			Property:    verr.Property,
+			Path:        verr.Path,
+			Value:       verr.Value,
+			Restriction: verr.Restriction,
+		}
+		if !reflect.DeepEqual(got, v.err) {
+			t.Errorf("Wrong error: %s -> %+v", k, got)
+		}
+	}
+
+	_, err = Validate(vr, NewGraph(nil, NewTriplet(a1, a2, trusts), NewTriplet(a1, a3, trusts)))
+	if !strings.HasPrefix(err.Error(), "Graph: ") {
+		t.Error("Wrong error: " + err.Error())
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Account:
+    properties:
+      name:
+        type: string
+        unique: true
+      balance:
+        type: int
+        restrictions:
+          min: 0
+    connections:
+      Account:
+        - edge: trusts
+          ratio:
+            min: 0
+            max: 1
+edges:
+  trusts:
+    properties:
+      amount:
+        type: int
+        restrictions:
+          min: 1
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	a1 := NewNode("Account", map[string]interface{}{"name": "A", "balance": -1, "extra": true})
+	a2 := NewNode("Account", map[string]interface{}{"name": "A", "balance": "zero"})
+	a3 := NewNode("Account", map[string]interface{}{"name": "C", "balance": 1})
+	trusts := func(amount int) Edge {
+		return NewEdge("trusts", map[string]interface{}{"amount": amount})
+	}
+	gr := NewGraph(nil, NewTriplet(a1, a2, trusts(0)), NewTriplet(a1, a3, trusts(5)))
+
+	if ok, err := ValidateAll(vr, NewGraph(nil, NewTriplet(a3, NewNode("Account", map[string]interface{}{"name": "D", "balance": 0}), trusts(1))), 0); !ok {
+		t.Error("Is NOT valid: graph -> " + err.Error())
+	}
+	if ok, err := Validate(vr, gr); ok || strings.Contains(err.Error(), "\n") {
+		t.Error("Validate doesn't stop at the first error")
+	}
+
+	ok, err := ValidateAll(vr, gr, 0)
+	report, isReport := err.(*ValidationReport)
+	if ok || !isReport {
+		t.Fatalf("Wrong result of ValidateAll: %v, %v", ok, err)
+	}
+	codes := make(map[ErrorCode]int)
+	for _, err := range report.Errors() {
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Error("Error is NOT ValidationError -> " + err.Error())
+			continue
+		}
+		codes[verr.Code] += 1
+	}
+	expected := map[ErrorCode]int{
+		ErrExtraProperty: 1,
+		ErrRestriction:   2,
+		ErrDataType:      1,
+		ErrCardinality:   1,
+		ErrUnique:        1,
+	}
+	if !reflect.DeepEqual(codes, expected) || report.Truncated {
+		t.Errorf("Wrong report: %v -> %s", codes, report.Error())
+	}
+	// a1 and a2 nodes and edge between them
+	if len(report.Entities) != 3 {
+		t.Errorf("Wrong amount of entities: %d -> %s", len(report.Entities), report.Error())
+	}
+	for _, e := range report.Entities {
+		if tr, ok := e.Entity.(Triplet); ok && tr.Edge().GetEdgeType() != "trusts" {
+			t.Errorf("Wrong entity of edge errors: %v", e.Entity)
+		}
+	}
+	if !strings.HasPrefix(report.Error(), "6 validation errors of 3 entities\n") {
+		t.Error("Wrong error: " + report.Error())
+	}
+
+	_, err = ValidateAll(vr, gr, 2)
+	if report, ok := err.(*ValidationReport); !ok || len(report.Errors()) != 2 || !report.Truncated {
+		t.Errorf("Wrong truncated report: %v", err)
+	}
+
+	_, err = ValidateAll(vr, a1, 0)
+	if report, ok := err.(*ValidationReport); !ok || len(report.Entities) != 1 || len(report.Errors()) != 2 {
+		t.Errorf("Wrong report of node: %v", err)
+	}
+
+	vr, err = ParseTemplate(strings.NewReader(`
+nodes:
+  Order:
+    when:
+      - if:
+          status: shipped
+        then:
+          required: [shipped_at, tracking]
+          properties:
+            tracking:
+              restrictions:
+                regexps: ["^[A-Z0-9]+$"]
+    properties:
+      status:
+        type: string
+      shipped_at:
+        type: datetime
+        required: false
+      tracking:
+        type: string
+        required: false
+edges:
+  pays:
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	order := NewNode("Order", map[string]interface{}{"status": "shipped", "tracking": "ab12"})
+	_, err = ValidateAll(vr, order, 0)
+	report, isReport = err.(*ValidationReport)
+	if !isReport || len(report.Errors()) != 2 {
+		t.Fatalf("Wrong report of conditions: %v", err)
+	}
+	for i, prop := range []string{"tracking", "shipped_at"} {
+		var verr *ValidationError
+		if !errors.As(report.Errors()[i], &verr) || verr.Code != ErrCondition || verr.Property != prop {
+			t.Errorf("Wrong error of %q property: %v", prop, report.Errors()[i])
+		}
+	}
+}
+
+func TestKeyedGraph(t *testing.T) {
+	vr, err := ParseTemplate(strings.NewReader(`
+nodes:
+  Person:
+    key: [id]
+    properties:
+      id:
+        type: int
+      name:
+        type: string
+    connections:
+      Person:
+        - edge: knows
+          ratio:
+            min: 0
+            max: -1
+edges:
+  knows:
+    key: [id]
+    properties:
+      id:
+        type: int
+      since:
+        type: int
+`))
+	if err != nil {
+		t.Fatal("Template is NOT parsed -> " + err.Error())
+	}
+	p1 := NewNode("Person", map[string]interface{}{"id": 1, "name": "Jora"})
+	p2 := NewNode("Person", map[string]interface{}{"id": 2, "name": "Vasya"})
+	knows := NewEdge("knows", map[string]interface{}{"id": 1, "since": 2000})
+
+	gr := NewKeyedGraph(GraphKeys(vr), nil,
+		NewTriplet(p1, p2, knows),
+		NewTriplet(NewNode("Person", map[string]interface{}{"id": 1, "name": "Jora Ivanov"}), p2, knows),
+	)
+	if len(gr.GetNodes()) != 2 || len(gr.GetTriplets()) != 1 {
+		t.Error("Nodes and edges with equal keys are NOT collapsed")
+	}
+	up, ok := gr.(Updater)
+	if !ok {
+		t.Fatal("Graph doesn't implement Updater-interface")
+	}
+	renamed := NewNode("Person", map[string]interface{}{"id": 1, "name": "Jora Ivanov"})
+	if !up.UpdateNode(renamed) || len(gr.GetNodeChilds(NewNode("Person", map[string]interface{}{"id": 1}))) != 1 {
+		t.Error("Node is NOT updated by key")
+	}
+	for _, n := range gr.GetNodesByType("Person") {
+		id, _ := n.GetProp("id")
+		name, _ := n.GetProp("name")
+		if id == 1 && name != "Jora Ivanov" {
+			t.Error("Node is NOT updated by key")
+		}
+	}
+	later := NewEdge("knows", map[string]interface{}{"id": 1, "since": 2010})
+	if !up.UpdateEdge(NewTriplet(renamed, p2, later)) {
+		t.Error("Edge is NOT updated by key")
+	}
+	if since, _ := gr.GetTriplets()[0].Edge().GetProp("since"); since != 2010 {
+		t.Error("Edge is NOT updated by key")
+	}
+	if ok, err := Validate(vr, gr); !ok {
+		t.Error("Is NOT valid: keyed graph -> " + err.Error())
+	}
+	if !gr.RemoveNode(NewNode("Person", map[string]interface{}{"id": 2})) || len(gr.GetTriplets()) != 0 {
+		t.Error("Node is NOT removed by key")
+	}
+}
+
 func TestValidateEdge(t *testing.T) {
 	friend := NewEdge("friend", map[string]interface{}{
 		"since": testTime,
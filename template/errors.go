@@ -0,0 +1,244 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Type that represents machine-readable code of validation error
+type ErrorCode string
+
+// Codes of validation errors; they are stable, so they may be used to
+// map errors (e.g. to fields of API)
+const (
+	// node or edge type isn't defined within template
+	ErrUnknownType ErrorCode = "unknown_type"
+	// validated value isn't node or edge of template (see ValidateUnknown)
+	ErrUnknownValue ErrorCode = "unknown_value"
+	// entity has property which isn't defined within template
+	ErrExtraProperty ErrorCode = "extra_property"
+	// entity doesn't have required property
+	ErrMissingProperty ErrorCode = "missing_property"
+	// not nullable property holds nil value
+	ErrNullValue ErrorCode = "null_value"
+	// value doesn't match data type of property
+	ErrDataType ErrorCode = "data_type"
+	// value doesn't satisfy restriction of property
+	ErrRestriction ErrorCode = "restriction"
+	// entity doesn't satisfy requirement of met condition
+	ErrCondition ErrorCode = "condition"
+	// entity doesn't satisfy constraint
+	ErrConstraint ErrorCode = "constraint"
+	// nodes are connected by edge which isn't declared within template
+	ErrConnection ErrorCode = "connection"
+	// amount of connections doesn't satisfy ratio of connection
+	ErrCardinality ErrorCode = "cardinality"
+	// values of unique properties are duplicated among nodes
+	ErrUnique ErrorCode = "unique"
+	// subgraph formed by edges doesn't satisfy structural constraint
+	ErrStructure ErrorCode = "structure"
+)
+
+// Type of errors which are returned by validation - Code is machine-readable
+// code of error; EntityType is type name of invalid node or edge; Property is
+// key of invalid property and Path locates invalid value within it - the same
+// as Property for "simple" values and with indexes of arrays items and keys of
+// maps entries for "inner" values (for example "tags[1]" or "limits[daily]");
+// Value is invalid value (or amount of connections for cardinality errors);
+// Restriction is name of unsatisfied restriction (or expression of unsatisfied
+// constraint, or structural constraint of edge); fields which don't relate to
+// error are empty
+//
+// Validation error may be wrapped by errors of entities which contain it (for
+// example errors of graph), so it should be obtained using errors.As
+type ValidationError struct {
+	Code        ErrorCode
+	EntityType  string
+	Property    string
+	Path        string
+	Value       interface{}
+	Restriction string
+	msg         string
+}
+
+// Common Error-method to implement error-interface
+func (e *ValidationError) Error() string {
+	return e.msg
+}
+
+// Creates and returns validation error with code of entity with typ type
+// name; error message is formatted accordingly to format
+func newValidationError(code ErrorCode, typ, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{
+		Code:       code,
+		EntityType: typ,
+		msg:        fmt.Sprintf(format, args...),
+	}
+}
+
+// Creates and returns validation error with code of v value of tp
+// property; error message is formatted accordingly to format
+func (tp TProperty) newError(code ErrorCode, v interface{}, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{
+		Code:     code,
+		Property: tp.Key,
+		Path:     tp.Key,
+		Value:    v,
+		msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// Creates and returns cardinality validation error of node with typ type
+// name which has n connections, which doesn't satisfy restr ratio bound
+// (min, max, incoming min or incoming max); error message is formatted
+// accordingly to format
+func cardinalityError(typ string, n int, restr, format string, args ...interface{}) *ValidationError {
+	res := newValidationError(ErrCardinality, typ, format, args...)
+	res.Value, res.Restriction = n, restr
+	return res
+}
+
+// Creates and returns structure validation error of subgraph formed by
+// edges with typ type name, which doesn't satisfy restr structural
+// constraint (acyclic, tree, connected or max_depth); error message is
+// formatted accordingly to format
+func structureError(typ, restr, format string, args ...interface{}) *ValidationError {
+	res := newValidationError(ErrStructure, typ, format, args...)
+	res.Restriction = restr
+	return res
+}
+
+// Creates and returns validation error of v value of tp property which
+// doesn't satisfy restriction described by err (see matchRestrs); error
+// message is formatted accordingly to format
+func (tp TProperty) restrictionError(v interface{}, err error, format string, args ...interface{}) *ValidationError {
+	res := tp.newError(ErrRestriction, v, format, args...)
+	if ve, ok := err.(*ValidationError); ok {
+		res.Code, res.Restriction = ve.Code, ve.Restriction
+	}
+	return res
+}
+
+// Sets typ entity type of validation error within err (if it isn't set
+// yet) and returns err as is
+func withEntityType(err error, typ string) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) && ve.EntityType == "" {
+		ve.EntityType = typ
+	}
+	return err
+}
+
+// Sets path of validation error within err as located within "inner" value
+// of property by at index of array item or key of map entry (for example
+// "[1]"); returns err as is
+func withPathPrefix(err error, key, at string) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) && len(ve.Path) >= len(key) {
+		ve.Path = key + at + ve.Path[len(key):]
+	}
+	return err
+}
+
+// Type of errors which are returned by collecting validation (see
+// TemplateHolder.ValidateAll) - Entities contains errors grouped by invalid
+// entities in order of their validation; Truncated is true if there were
+// more errors than limit of collected errors
+type ValidationReport struct {
+	Entities  []EntityErrors
+	Truncated bool
+}
+
+// Type that represents errors of the single invalid entity within
+// ValidationReport - Entity is invalid node, edge (edges of graph are
+// presented as triplets with their main and subject nodes), duplet or
+// triplet (nil for errors of graph structure); Errors contains errors of
+// entity in the same form as validation of entity returns them
+type EntityErrors struct {
+	Entity interface{}
+	Errors []error
+}
+
+// Common Error-method to implement error-interface; message contains ALL
+// collected errors - one per line
+func (r *ValidationReport) Error() string {
+	errs := r.Errors()
+	msgs := make([]string, 0, len(errs)+1)
+	head := fmt.Sprintf("%d validation errors of %d entities", len(errs), len(r.Entities))
+	if r.Truncated {
+		head += " (truncated)"
+	}
+	msgs = append(msgs, head)
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Returns ALL collected errors of ALL entities as flat list
+func (r *ValidationReport) Errors() []error {
+	res := make([]error, 0)
+	for _, e := range r.Entities {
+		res = append(res, e.Errors...)
+	}
+	return res
+}
+
+// Type of funcs which receive validation errors; func returns false if
+// validation should be interrupted
+type reporter func(err error) bool
+
+// Returns reporter which keeps the first error within res and interrupts
+// validation
+func firstError(res *error) reporter {
+	return func(err error) bool {
+		*res = err
+		return false
+	}
+}
+
+// Returns reporter which prefixes errors by prefix (keeping them wrapped)
+// and passes them to report
+func prefixed(report reporter, prefix string) reporter {
+	return func(err error) bool {
+		return report(fmt.Errorf("%s: %w", prefix, err))
+	}
+}
+
+// Buffer type that collects validation errors into report grouped by
+// entities; limit is maximum amount of collected errors (non-positive
+// limit means there is no limit) and count is amount of collected errors
+type collector struct {
+	report *ValidationReport
+	limit  int
+	count  int
+}
+
+// Creates and returns new collector with limit of collected errors
+func newCollector(limit int) *collector {
+	return &collector{
+		report: &ValidationReport{Entities: make([]EntityErrors, 0)},
+		limit:  limit,
+	}
+}
+
+// Returns reporter which collects errors of e entity into its own group
+// (group is created at the first error); reporter interrupts validation
+// if limit of collected errors is exceeded
+func (c *collector) group(e interface{}) reporter {
+	i := -1
+	return func(err error) bool {
+		if c.limit > 0 && c.count >= c.limit {
+			c.report.Truncated = true
+			return false
+		}
+		if i == -1 {
+			c.report.Entities = append(c.report.Entities, EntityErrors{Entity: e})
+			i = len(c.report.Entities) - 1
+		}
+		c.report.Entities[i].Errors = append(c.report.Entities[i].Errors, err)
+		c.count += 1
+		return true
+	}
+}
@@ -0,0 +1,644 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Template constraint type - represents boolean expression which relates
+// several properties of the same entity (for example "end > start"); Expr
+// contains expression in the form which is used within template-file
+//
+// Expression may contain:
+//   - int, float, string ("..." or '...') and bool (true, false) literals,
+//   - property keys (keys with special symbols should be enclosed within
+//     backticks - `first-name`),
+//   - arithmetic operators (+, -, *, /, %; + also concatenates strings),
+//   - comparison operators (==, !=, <, <=, >, >=; datetimes, dates, durations,
+//     int64, uint, decimal and custom data types properties are comparable
+//     with properties of the same data type),
+//   - logical operators (&&, ||, !) and parentheses,
+//   - len-function which returns length of string, bytes, array or map
+//
+// Constraint is considered satisfied if any of properties used within it is
+// absent or holds nil value
+type TConstraint struct {
+	Expr string
+	root *exprNode
+}
+
+// Parses expr expression and checks it against ps properties (all used
+// properties should be defined within ps and operators should be applied
+// to values of suitable data types); returns TConstraint-struct and nil on
+// success
+func NewConstraint(expr string, ps map[string]*TProperty) (*TConstraint, error) {
+	p := &exprParser{expr: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].at+1)
+	}
+	if err := root.check(ps); err != nil {
+		return nil, err
+	}
+	if root.typ != TBool {
+		return nil, fmt.Errorf("expression should have bool result, not %s", root.typ)
+	}
+	return &TConstraint{Expr: expr, root: root}, nil
+}
+
+// Evaluates constraint using get func, which returns properties of validated
+// entity; returns true and nil if constraint is satisfied
+func (c TConstraint) evaluate(get func(string) (interface{}, bool)) (bool, error) {
+	for _, k := range c.root.props() {
+		if v, ok := get(k); !ok || v == nil {
+			return true, nil
+		}
+	}
+	res, err := c.root.eval(get)
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// Type that represents kind of expression node
+type exprOp uint8
+
+const (
+	opLiteral exprOp = iota
+	opProp
+	opLen
+	opNot
+	opNeg
+	opOr
+	opAnd
+	opEq
+	opNe
+	opLt
+	opLe
+	opGt
+	opGe
+	opAdd
+	opSub
+	opMul
+	opDiv
+	opMod
+)
+
+// Textual representation of binary operators which is used within
+// expressions and errors
+var binaryOps = map[string]exprOp{
+	"||": opOr,
+	"&&": opAnd,
+	"==": opEq,
+	"!=": opNe,
+	"<":  opLt,
+	"<=": opLe,
+	">":  opGt,
+	">=": opGe,
+	"+":  opAdd,
+	"-":  opSub,
+	"*":  opMul,
+	"/":  opDiv,
+	"%":  opMod,
+}
+
+// Node of parsed expression; val contains value of literal or key of
+// property, text contains operator as it's written within expression
+type exprNode struct {
+	op   exprOp
+	typ  TDataType
+	val  interface{}
+	text string
+	args []*exprNode
+}
+
+// Lexical token of expression; at is position of token within expression
+type exprToken struct {
+	kind byte // 'n' - number, 's' - string, 'i' - identifier, 'o' - operator
+	text string
+	val  interface{}
+	at   int
+}
+
+// Buffer type for parsing of expression using recursive descent
+type exprParser struct {
+	expr   string
+	tokens []exprToken
+	pos    int
+}
+
+// Splits expression into tokens; returns nil on success
+func (p *exprParser) tokenize() error {
+	s := p.expr
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i
+			isFloat := false
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				isFloat = isFloat || s[j] == '.'
+				j++
+			}
+			text := s[i:j]
+			var val interface{}
+			var err error
+			if isFloat {
+				val, err = strconv.ParseFloat(text, 64)
+			} else {
+				val, err = strconv.Atoi(text)
+			}
+			if err != nil {
+				return fmt.Errorf("wrong number %q at position %d", text, i+1)
+			}
+			p.tokens = append(p.tokens, exprToken{'n', text, val, i})
+			i = j
+		case r == '"' || r == '\'' || r == '`':
+			j := i + 1
+			for j < len(s) && s[j] != byte(r) {
+				if s[j] == '\\' && r != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return fmt.Errorf("unclosed %c at position %d", r, i+1)
+			}
+			text := s[i : j+1]
+			switch r {
+			case '`':
+				p.tokens = append(p.tokens, exprToken{'i', text, s[i+1 : j], i})
+			default:
+				val, err := strconv.Unquote(`"` + strings.ReplaceAll(s[i+1:j], `\'`, `'`) + `"`)
+				if r == '"' {
+					val, err = strconv.Unquote(text)
+				}
+				if err != nil {
+					return fmt.Errorf("wrong string %s at position %d", text, i+1)
+				}
+				p.tokens = append(p.tokens, exprToken{'s', text, val, i})
+			}
+			i = j + 1
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			text := s[i:j]
+			p.tokens = append(p.tokens, exprToken{'i', text, text, i})
+			i = j
+		default:
+			text := string(r)
+			if i+1 < len(s) {
+				if _, ok := binaryOps[s[i:i+2]]; ok {
+					text = s[i : i+2]
+				}
+			}
+			if _, ok := binaryOps[text]; !ok && !strings.Contains("!(),", text) {
+				return fmt.Errorf("unexpected %q at position %d", text, i+1)
+			}
+			p.tokens = append(p.tokens, exprToken{'o', text, nil, i})
+			i += len(text)
+		}
+	}
+	return nil
+}
+
+// Returns current token without moving to the next one; returns empty
+// token at the end of expression
+func (p *exprParser) peek() exprToken {
+	if p.pos >= len(p.tokens) {
+		return exprToken{at: len(p.expr)}
+	}
+	return p.tokens[p.pos]
+}
+
+// Moves to the next token if current token is operator with text; returns
+// true on success
+func (p *exprParser) accept(text string) bool {
+	if t := p.peek(); t.kind == 'o' && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// Returns error about unexpected current token
+func (p *exprParser) unexpected() error {
+	t := p.peek()
+	if t.kind == 0 {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", t.text, t.at+1)
+}
+
+// Parses binary operators with ops texts (left associative) using next func
+// for operands; operand may be compared only once if single is true
+func (p *exprParser) parseBinary(next func() (*exprNode, error), single bool, ops ...string) (*exprNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range ops {
+			if p.accept(o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: binaryOps[op], text: op, args: []*exprNode{left, right}}
+		if single {
+			return left, nil
+		}
+	}
+}
+
+// Parses disjunction - the lowest priority expression
+func (p *exprParser) parseOr() (*exprNode, error) {
+	return p.parseBinary(p.parseAnd, false, "||")
+}
+
+// Parses conjunction
+func (p *exprParser) parseAnd() (*exprNode, error) {
+	return p.parseBinary(p.parseCmp, false, "&&")
+}
+
+// Parses comparison
+func (p *exprParser) parseCmp() (*exprNode, error) {
+	return p.parseBinary(p.parseAdd, true, "==", "!=", "<=", ">=", "<", ">")
+}
+
+// Parses addition and subtraction
+func (p *exprParser) parseAdd() (*exprNode, error) {
+	return p.parseBinary(p.parseMul, false, "+", "-")
+}
+
+// Parses multiplication, division and remainder
+func (p *exprParser) parseMul() (*exprNode, error) {
+	return p.parseBinary(p.parseUnary, false, "*", "/", "%")
+}
+
+// Parses unary operators
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	for _, o := range []struct {
+		text string
+		op   exprOp
+	}{{"!", opNot}, {"-", opNeg}} {
+		if p.accept(o.text) {
+			arg, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return &exprNode{op: o.op, text: o.text, args: []*exprNode{arg}}, nil
+		}
+	}
+	return p.parsePrimary()
+}
+
+// Parses literals, properties, function calls and parentheses
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	t := p.peek()
+	switch t.kind {
+	case 'n', 's':
+		p.pos++
+		return &exprNode{op: opLiteral, val: t.val, text: t.text}, nil
+	case 'i':
+		p.pos++
+		switch {
+		case t.text == "true" || t.text == "false":
+			return &exprNode{op: opLiteral, val: t.text == "true", text: t.text}, nil
+		case p.accept("("):
+			if t.text != "len" {
+				return nil, fmt.Errorf("unknown function %q at position %d", t.text, t.at+1)
+			}
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, p.unexpected()
+			}
+			return &exprNode{op: opLen, text: t.text, args: []*exprNode{arg}}, nil
+		}
+		return &exprNode{op: opProp, val: t.val, text: t.text}, nil
+	case 'o':
+		if p.accept("(") {
+			res, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, p.unexpected()
+			}
+			return res, nil
+		}
+	}
+	return nil, p.unexpected()
+}
+
+// Returns true if t is int or float data type
+func isNumeric(t TDataType) bool {
+	return t == TInt || t == TFloat
+}
+
+// Returns true if values of t data type (except of numeric ones) may be
+// ordered with each other (values of custom data types are ordered using
+// their Compare method)
+func isOrdered(t TDataType) bool {
+	switch t {
+	case TString, TDateTime, TInt64, TUint, TDecimal, TDuration, TDate:
+		return true
+	}
+	return isCustom(t)
+}
+
+// Detects data type of expression node and checks that properties are
+// defined within ps and operators are applied to suitable data types;
+// returns nil on success
+func (n *exprNode) check(ps map[string]*TProperty) error {
+	for _, arg := range n.args {
+		if err := arg.check(ps); err != nil {
+			return err
+		}
+	}
+	switch n.op {
+	case opLiteral:
+		switch n.val.(type) {
+		case int:
+			n.typ = TInt
+		case float64:
+			n.typ = TFloat
+		case string:
+			n.typ = TString
+		case bool:
+			n.typ = TBool
+		}
+		return nil
+	case opProp:
+		p, ok := ps[n.val.(string)]
+		if !ok {
+			return fmt.Errorf("undefined property %q", n.val)
+		}
+		n.typ = p.Typ
+		return nil
+	case opLen:
+		if t := n.args[0].typ; t != TString && t != TBytes && t != TArray && t != TMap {
+			return fmt.Errorf("function \"len\" can't be applied to %s", t)
+		}
+		n.typ = TInt
+		return nil
+	case opNot:
+		if t := n.args[0].typ; t != TBool {
+			return fmt.Errorf("operator %q can't be applied to %s", n.text, t)
+		}
+		n.typ = TBool
+		return nil
+	case opNeg:
+		if t := n.args[0].typ; !isNumeric(t) {
+			return fmt.Errorf("operator %q can't be applied to %s", n.text, t)
+		}
+		n.typ = n.args[0].typ
+		return nil
+	}
+
+	l, r := n.args[0].typ, n.args[1].typ
+	ok := false
+	switch n.op {
+	case opOr, opAnd:
+		ok, n.typ = l == TBool && r == TBool, TBool
+	case opEq, opNe:
+		ok, n.typ = (l == r || isNumeric(l) && isNumeric(r)) && l != TArray && l != TMap, TBool
+	case opLt, opLe, opGt, opGe:
+		ok, n.typ = l == r && isOrdered(l) || isNumeric(l) && isNumeric(r), TBool
+	case opAdd:
+		ok = isNumeric(l) && isNumeric(r) || l == TString && r == TString
+		n.typ = l
+		if l != r {
+			n.typ = TFloat
+		}
+	case opSub, opMul, opDiv:
+		ok, n.typ = isNumeric(l) && isNumeric(r), l
+		if l != r {
+			n.typ = TFloat
+		}
+	case opMod:
+		ok, n.typ = l == TInt && r == TInt, TInt
+	}
+	if !ok {
+		return fmt.Errorf("operator %q can't be applied to %s and %s", n.text, l, r)
+	}
+	return nil
+}
+
+// Returns keys of all properties which are used within expression node
+func (n *exprNode) props() []string {
+	if n.op == opProp {
+		return []string{n.val.(string)}
+	}
+	res := make([]string, 0)
+	for _, arg := range n.args {
+		res = append(res, arg.props()...)
+	}
+	return res
+}
+
+// Evaluates expression node using get func, which returns properties of
+// validated entity; returns result and nil on success
+func (n *exprNode) eval(get func(string) (interface{}, bool)) (interface{}, error) {
+	switch n.op {
+	case opLiteral:
+		return n.val, nil
+	case opProp:
+		v, _ := get(n.val.(string))
+		return toExprValue(n.typ, v)
+	case opOr, opAnd:
+		// short-circuit evaluation
+		l, err := n.args[0].eval(get)
+		if err != nil {
+			return nil, err
+		}
+		if l.(bool) == (n.op == opOr) {
+			return l, nil
+		}
+		return n.args[1].eval(get)
+	}
+
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(get)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	switch n.op {
+	case opLen:
+		if s, ok := args[0].(string); ok {
+			return utf8.RuneCountInString(s), nil
+		}
+		return reflect.ValueOf(args[0]).Len(), nil
+	case opNot:
+		return !args[0].(bool), nil
+	case opNeg:
+		if v, ok := args[0].(int); ok {
+			return -v, nil
+		}
+		return -args[0].(float64), nil
+	}
+	return evalBinary(n.op, n.text, args[0], args[1])
+}
+
+// Evaluates binary operator op (with text representation) with l and r
+// operands; returns result and nil on success
+func evalBinary(op exprOp, text string, l, r interface{}) (interface{}, error) {
+	switch lv := l.(type) {
+	case string:
+		rv := r.(string)
+		switch op {
+		case opAdd:
+			return lv + rv, nil
+		case opEq, opNe, opLt, opLe, opGt, opGe:
+			return compareResult(op, strings.Compare(lv, rv)), nil
+		}
+	case bool:
+		return (lv == r.(bool)) == (op == opEq), nil
+	case time.Time:
+		res, _ := compareValues(lv, r)
+		return compareResult(op, res), nil
+	case int64, uint64, time.Duration, *big.Rat, TCustomValue:
+		res, _ := compareValues(lv, r)
+		return compareResult(op, res), nil
+	case []byte:
+		return bytes.Equal(lv, r.([]byte)) == (op == opEq), nil
+	case int:
+		if rv, ok := r.(int); ok {
+			switch op {
+			case opAdd:
+				return lv + rv, nil
+			case opSub:
+				return lv - rv, nil
+			case opMul:
+				return lv * rv, nil
+			case opDiv, opMod:
+				if rv == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				if op == opDiv {
+					return lv / rv, nil
+				}
+				return lv % rv, nil
+			}
+			res, _ := compareValues(lv, rv)
+			return compareResult(op, res), nil
+		}
+	}
+
+	// mixed int and float operands are evaluated as floats
+	lf, rf := toFloat(l), toFloat(r)
+	switch op {
+	case opAdd:
+		return lf + rf, nil
+	case opSub:
+		return lf - rf, nil
+	case opMul:
+		return lf * rf, nil
+	case opDiv:
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case opEq, opNe, opLt, opLe, opGt, opGe:
+		res, _ := compareValues(lf, rf)
+		return compareResult(op, res), nil
+	}
+	return nil, fmt.Errorf("operator %q can't be evaluated", text)
+}
+
+// Returns result of comparison operator op using res result of comparison
+// (-1, 0 or +1) of its operands
+func compareResult(op exprOp, res int) bool {
+	switch op {
+	case opEq:
+		return res == 0
+	case opNe:
+		return res != 0
+	case opLt:
+		return res < 0
+	case opLe:
+		return res <= 0
+	case opGt:
+		return res > 0
+	case opGe:
+		return res >= 0
+	}
+	return false
+}
+
+// Returns v int or float value as float
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+	f, _ := assertFloat(v)
+	return f
+}
+
+// Asserts v property value to value of t data type which is used within
+// expressions; returns asserted value and nil on success
+func toExprValue(t TDataType, v interface{}) (interface{}, error) {
+	var (
+		res interface{}
+		ok  bool
+	)
+	switch t {
+	case TInt:
+		res, ok = assertInt(v)
+	case TFloat:
+		res, ok = assertFloat(v)
+	case TString:
+		res, ok = assertString(v)
+	case TBool:
+		res, ok = assertBool(v)
+	case TDateTime:
+		res, ok = assertDateTime(v)
+	case TInt64, TUint, TDecimal, TDuration, TDate, TUUID, TBytes:
+		res, ok = toCanonical(t, v)
+	case TArray, TMap:
+		k := reflect.ValueOf(v).Kind()
+		res, ok = v, k == reflect.Slice || k == reflect.Array || k == reflect.Map
+	default:
+		res, ok = assertCustom(t, v)
+	}
+	if !ok {
+		return nil, fmt.Errorf("\"%v\" value doesn't match %q data type", v, t)
+	}
+	return res, nil
+}
@@ -0,0 +1,629 @@
+package template
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// template-file itself
+type mTemplate struct {
+	Settings *mSettings            `yaml:"settings,omitempty"`
+	Types    map[string]*mProperty `yaml:"types,omitempty"`
+	Labels   map[string]*mEntity   `yaml:"labels,omitempty"`
+	Nodes    map[string]*mEntity   `yaml:"nodes"`
+	Edges    map[string]*mEntity   `yaml:"edges"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// settings-field of template-file
+type mSettings struct {
+	DateTime mDateTime `yaml:"datetime"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// datetime-subfield of settings
+type mDateTime struct {
+	Layout   string `yaml:"layout"`
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// labels-, nodes- and edges-fields of template-file
+type mEntity struct {
+	Direction string                   `yaml:"direction,omitempty"`
+	Labels    []string                 `yaml:"labels,omitempty"`
+	Props     map[string]*mProperty    `yaml:"properties,omitempty"`
+	Conns     map[string][]mConnection `yaml:"connections,omitempty"`
+	Unique    [][]string               `yaml:"unique_together,omitempty"`
+	Key       []string                 `yaml:"key,omitempty"`
+	Consts    []string                 `yaml:"constraints,omitempty"`
+	When      []mCondition             `yaml:"when,omitempty"`
+	Acyclic   bool                     `yaml:"acyclic,omitempty"`
+	Tree      bool                     `yaml:"tree,omitempty"`
+	Connected bool                     `yaml:"connected,omitempty"`
+	MaxDepth  *int                     `yaml:"max_depth,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// element of when-field within "nodes" and "edges"
+type mCondition struct {
+	If   map[string]string `yaml:"if"`
+	Then mConditionThen    `yaml:"then"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// then-subfield of condition
+type mConditionThen struct {
+	Required []string                       `yaml:"required,omitempty"`
+	Props    map[string]*mConditionProperty `yaml:"properties,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// property within then-subfield of condition
+type mConditionProperty struct {
+	Restrs *mRestrictions `yaml:"restrictions,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// subfileds of properties-field within "nodes", "labels" and "edges"
+type mProperty struct {
+	Type     string         `yaml:"type"`
+	Required *bool          `yaml:"required,omitempty"`
+	Nullable *bool          `yaml:"nullable,omitempty"`
+	Unique   bool           `yaml:"unique,omitempty"`
+	Default  *string        `yaml:"default,omitempty"`
+	Restrs   *mRestrictions `yaml:"restrictions,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// restriction-subfiled of property-field
+type mRestrictions struct {
+	Values      []string       `yaml:"values,omitempty"`
+	Regexps     []string       `yaml:"regexps,omitempty"`
+	Validators  []string       `yaml:"validators,omitempty"`
+	KeyValues   []string       `yaml:"key_values,omitempty"`
+	KeyRegexps  []string       `yaml:"key_regexps,omitempty"`
+	Min         *string        `yaml:"min,omitempty"`
+	Max         *string        `yaml:"max,omitempty"`
+	ExclMin     *string        `yaml:"exclusive_min,omitempty"`
+	ExclMax     *string        `yaml:"exclusive_max,omitempty"`
+	MinLength   *int           `yaml:"min_length,omitempty"`
+	MaxLength   *int           `yaml:"max_length,omitempty"`
+	MinItems    *int           `yaml:"min_items,omitempty"`
+	MaxItems    *int           `yaml:"max_items,omitempty"`
+	UniqueItems bool           `yaml:"unique_items,omitempty"`
+	MinEntries  *int           `yaml:"min_entries,omitempty"`
+	MaxEntries  *int           `yaml:"max_entries,omitempty"`
+	Inner       *mRestrictions `yaml:"inner,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// subfileds of connections-field within "nodes" and "labels"
+type mConnection struct {
+	Edge          string                `yaml:"edge"`
+	Ratio         mRatio                `yaml:"ratio"`
+	Incoming      *mRatio               `yaml:"incoming,omitempty"`
+	Bidirectional bool                  `yaml:"bidirectional,omitempty"`
+	Props         map[string]*mProperty `yaml:"properties,omitempty"`
+	Polymorphic   bool                  `yaml:"polymorphic,omitempty"`
+}
+
+// Temporal buffer type for .yaml serialization purposes; represents
+// ratio- and incoming-subfileds of connection-field
+type mRatio struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// Serializes t template into canonical .yaml template-file (with sorted
+// type names, properties and connections) which keeps labels as is;
+// properties and connections of nodes which are inherited from labels
+// are omitted, so parsing of the result gives the same template as t
+func Marshal(t TemplateHolder) ([]byte, error) {
+	return marshal(t, false)
+}
+
+// Serializes t template into canonical .yaml template-file (with sorted
+// type names, properties and connections) where labels are fully
+// expanded - all properties and connections of labels are embedded
+// within according nodes and labels themselves are omitted; parsing of
+// the result gives the same template as t excluding labels (so unique
+// properties of labels become unique among nodes of according node type
+// only and ratios of polymorphic label connections are applied to each
+// node type separately)
+func MarshalExpanded(t TemplateHolder) ([]byte, error) {
+	return marshal(t, true)
+}
+
+// Serializes t template into canonical .yaml template-file; if expand
+// is true - labels are fully expanded within according nodes
+func marshal(t TemplateHolder, expand bool) ([]byte, error) {
+	res := &mTemplate{
+		Types: make(map[string]*mProperty, len(t.Types)),
+		Nodes: make(map[string]*mEntity, len(t.Nodes)),
+		Edges: make(map[string]*mEntity, len(t.Edges)),
+	}
+	if t.DateTime != nil {
+		res.Settings = &mSettings{
+			DateTime: mDateTime{Layout: t.DateTime.Layout},
+		}
+		if t.DateTime.Location != nil {
+			res.Settings.DateTime.Timezone = t.DateTime.Location.String()
+		}
+	}
+
+	for k, v := range t.Types {
+		p, err := t.marshalProperty(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q named type: %s", k, err.Error())
+		}
+		res.Types[k] = p
+	}
+	for k, v := range t.Edges {
+		props, err := t.marshalProperties(v.Props, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%q-edge: %s", k, err.Error())
+		}
+		when, err := marshalConditions(v.When, v.Props)
+		if err != nil {
+			return nil, fmt.Errorf("%q-edge: %s", k, err.Error())
+		}
+		res.Edges[k] = &mEntity{
+			Props:     props,
+			Key:       v.Key,
+			Consts:    t.marshalConstraints(v.Constraints, nil),
+			When:      when,
+			Acyclic:   v.Acyclic,
+			Tree:      v.Tree,
+			Connected: v.Connected,
+		}
+		if v.MaxDepth != 0 {
+			depth := v.MaxDepth
+			res.Edges[k].MaxDepth = &depth
+		}
+		if v.Undirected {
+			res.Edges[k].Direction = "undirected"
+		}
+	}
+	if !expand {
+		res.Labels = make(map[string]*mEntity, len(t.Labels))
+		for k, v := range t.Labels {
+			props, err := t.marshalProperties(v.Props, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%q-label: %s", k, err.Error())
+			}
+			res.Labels[k] = &mEntity{
+				Labels: v.Labels,
+				Props:  props,
+				Conns:  make(map[string][]mConnection),
+				Unique: v.UniqueTogether,
+				Consts: t.marshalConstraints(v.Constraints, nil),
+			}
+		}
+		for m, ss := range t.LConns {
+			for s, es := range ss {
+				for e, v := range es {
+					conn := marshalConnection(e, v.Min, v.Max, v.InMin, v.InMax, v.Bidirectional)
+					props, err := t.marshalProperties(v.Props, nil)
+					if err != nil {
+						return nil, fmt.Errorf("%q-label: %q-edge connection: %s", m, e, err.Error())
+					}
+					conn.Props = props
+					conn.Polymorphic = v.Polymorphic
+					res.Labels[m].Conns[s] = append(res.Labels[m].Conns[s], conn)
+				}
+				sortConnections(res.Labels[m].Conns[s])
+			}
+		}
+	}
+	for k, v := range t.Nodes {
+		var (
+			labels    []string
+			inherited map[string]bool
+		)
+		if !expand {
+			labels = v.Labels
+			inherited = t.inheritedLabels(v.Labels)
+		}
+		props, err := t.marshalProperties(v.Props, inherited)
+		if err != nil {
+			return nil, fmt.Errorf("%q-node: %s", k, err.Error())
+		}
+		when, err := marshalConditions(v.When, v.Props)
+		if err != nil {
+			return nil, fmt.Errorf("%q-node: %s", k, err.Error())
+		}
+		res.Nodes[k] = &mEntity{
+			Labels: labels,
+			Props:  props,
+			Conns:  make(map[string][]mConnection),
+			Unique: v.UniqueTogether,
+			Key:    v.Key,
+			Consts: t.marshalConstraints(v.Constraints, inherited),
+			When:   when,
+		}
+	}
+	for m, ss := range t.Conns {
+		for s, es := range ss {
+			for e, v := range es {
+				if !expand && t.isLabelConnection(m, s, e) {
+					continue
+				}
+				conn := marshalConnection(e, v.Min, v.Max, v.InMin, v.InMax, v.Bidirectional)
+				props, err := t.marshalProperties(v.Props, nil)
+				if err != nil {
+					return nil, fmt.Errorf("%q-node: %q-edge connection: %s", m, e, err.Error())
+				}
+				conn.Props = props
+				res.Nodes[m].Conns[s] = append(res.Nodes[m].Conns[s], conn)
+			}
+			sortConnections(res.Nodes[m].Conns[s])
+		}
+	}
+
+	return yaml.Marshal(res)
+}
+
+// Serializes ps properties; properties which are defined within labels
+// (with names within inherited) are omitted
+func (t TemplateHolder) marshalProperties(ps map[string]*TProperty, inherited map[string]bool) (map[string]*mProperty, error) {
+	res := make(map[string]*mProperty, len(ps))
+	for k, v := range ps {
+		if t.isLabelProperty(v, inherited) {
+			continue
+		}
+		p, err := t.marshalProperty(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q property: %s", k, err.Error())
+		}
+		res[k] = p
+	}
+	return res, nil
+}
+
+// Serializes p property; if p refers to named data type - serializes
+// only those definitions which differs from named data type definitions
+func (t TemplateHolder) marshalProperty(p *TProperty) (*mProperty, error) {
+	res := &mProperty{
+		Type:   marshalDataType(p),
+		Unique: p.Unique,
+	}
+	required, nullable, def := !p.Required, p.Nullable, p.Default != nil
+	if p.Named != "" {
+		named, ok := t.Types[p.Named]
+		if !ok {
+			return nil, fmt.Errorf("undefined named data type %q", p.Named)
+		}
+		res.Type = p.Named
+		required = p.Required != named.Required
+		nullable = p.Nullable != named.Nullable
+		def = def && !reflect.DeepEqual(p.Default, named.Default)
+	}
+	if required {
+		res.Required = &p.Required
+	}
+	if nullable {
+		res.Nullable = &p.Nullable
+	}
+	if def {
+		v := p.Default
+		if isCustom(p.Typ) {
+			// default values of custom data types are held in canonical form as is
+			v = TCustomValue{Typ: p.Typ, Val: v}
+		}
+		d, err := marshalValue(v, p.dateTimeFormat(p.Typ))
+		if err != nil {
+			return nil, fmt.Errorf("default value: %s", err.Error())
+		}
+		res.Default = &d
+	}
+	if p.Named != "" {
+		return res, nil
+	}
+
+	restrs, err := marshalRestrictions(p)
+	if err != nil {
+		return nil, err
+	}
+	res.Restrs = restrs
+	return res, nil
+}
+
+// Serializes restrictions of p property (including restrictions of its
+// "inner" values); returns nil if there is no any restriction
+func marshalRestrictions(p *TProperty) (*mRestrictions, error) {
+	res := &mRestrictions{}
+	for _, r := range append(append([]*TRestriction{}, p.ValRestrs...), p.KeyRestrs...) {
+		if r == nil {
+			continue
+		}
+		if r.RestrTyp == TUniqueItems {
+			res.UniqueItems = true
+			continue
+		}
+		sizes := map[TRestrictionType]**int{
+			TMinLength:  &res.MinLength,
+			TMaxLength:  &res.MaxLength,
+			TMinItems:   &res.MinItems,
+			TMaxItems:   &res.MaxItems,
+			TMinEntries: &res.MinEntries,
+			TMaxEntries: &res.MaxEntries,
+		}
+		if ptr, ok := sizes[r.RestrTyp]; ok {
+			n, ok := r.Restr.(int)
+			if !ok {
+				return nil, fmt.Errorf("%q restriction has wrong value %v", r.RestrTyp, r.Restr)
+			}
+			*ptr = &n
+			continue
+		}
+
+		v, err := marshalValue(r.Restr, p.dateTimeFormat(r.Typ))
+		if err != nil {
+			return nil, fmt.Errorf("%q restriction: %s", r.RestrTyp, err.Error())
+		}
+		switch r.RestrTyp {
+		case TValue:
+			res.Values = append(res.Values, v)
+		case TRegExp:
+			res.Regexps = append(res.Regexps, v)
+		case TValidator:
+			res.Validators = append(res.Validators, v)
+		case TKeyValue:
+			res.KeyValues = append(res.KeyValues, v)
+		case TKeyRegExp:
+			res.KeyRegexps = append(res.KeyRegexps, v)
+		case TMin:
+			res.Min = &v
+		case TMax:
+			res.Max = &v
+		case TExclusiveMin:
+			res.ExclMin = &v
+		case TExclusiveMax:
+			res.ExclMax = &v
+		default:
+			return nil, fmt.Errorf("undefined restriction type %d", r.RestrTyp)
+		}
+	}
+	if p.Elem != nil {
+		inner, err := marshalRestrictions(p.Elem)
+		if err != nil {
+			return nil, fmt.Errorf("inner %s", err.Error())
+		}
+		res.Inner = inner
+	}
+
+	if reflect.DeepEqual(*res, mRestrictions{}) {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// Serializes cs conditions of entity with ps properties (which are used
+// to serialize values of conditions accordingly to their data types)
+func marshalConditions(cs []*TCondition, ps map[string]*TProperty) ([]mCondition, error) {
+	res := make([]mCondition, 0, len(cs))
+	for i, c := range cs {
+		mc := mCondition{
+			If: make(map[string]string, len(c.If)),
+			Then: mConditionThen{
+				Required: make([]string, 0),
+				Props:    make(map[string]*mConditionProperty),
+			},
+		}
+		for k, v := range c.If {
+			var f *TDateTimeFormat
+			if p, ok := ps[k]; ok {
+				f = p.dateTimeFormat(p.Typ)
+			}
+			val, err := marshalValue(v, f)
+			if err != nil {
+				return nil, fmt.Errorf("condition %d: %q property: %s", i+1, k, err.Error())
+			}
+			mc.If[k] = val
+		}
+		for k, p := range c.Props {
+			if p.Required {
+				mc.Then.Required = append(mc.Then.Required, k)
+			}
+			restrs, err := marshalRestrictions(p)
+			if err != nil {
+				return nil, fmt.Errorf("condition %d: %q property: %s", i+1, k, err.Error())
+			}
+			if restrs != nil || !p.Required {
+				mc.Then.Props[k] = &mConditionProperty{Restrs: restrs}
+			}
+		}
+		sort.Strings(mc.Then.Required)
+		res = append(res, mc)
+	}
+	return res, nil
+}
+
+// Returns data type of p property in the form which is used within
+// template-file; nested arrays and maps are written in recursive notation
+func marshalDataType(p *TProperty) string {
+	switch {
+	case p.Elem != nil:
+		return marshalNestedDataType(p)
+	case p.Typ == TArray:
+		return fmt.Sprintf("%s-%s", p.Typ, marshalValueType(p, p.ValTyp))
+	case p.Typ == TMap:
+		return fmt.Sprintf("%s-%s-%s", p.Typ, p.KeyTyp, marshalValueType(p, p.ValTyp))
+	}
+	return marshalValueType(p, p.Typ)
+}
+
+// Returns t "simple" data type of p property values in the form which is
+// used within template-file (with precision and scale of decimal values)
+func marshalValueType(p *TProperty, t TDataType) string {
+	if t == TDecimal && p.Precision != 0 {
+		return fmt.Sprintf("%s(%d,%d)", t, p.Precision, p.Scale)
+	}
+	return t.String()
+}
+
+// Returns data type of p property in recursive notation - "array<value
+// type>" or "map<key type,value type>"
+func marshalNestedDataType(p *TProperty) string {
+	val := marshalValueType(p, p.ValTyp)
+	if p.Elem != nil {
+		val = marshalNestedDataType(p.Elem)
+	}
+	switch p.Typ {
+	case TArray:
+		return fmt.Sprintf("%s<%s>", p.Typ, val)
+	case TMap:
+		return fmt.Sprintf("%s<%s,%s>", p.Typ, p.KeyTyp, val)
+	}
+	return p.Typ.String()
+}
+
+// Returns v value of restriction or default value in the form which is
+// used within template-file; datetime values are formatted using f
+// datetime format (RFC3339 if f is nil or if layout of f loses precision
+// of value, cus RFC3339 values are parsed regardless of layout)
+func marshalValue(v interface{}, f *TDateTimeFormat) (string, error) {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		s := f.format(v)
+		if !f.isExact(s, v) {
+			s = v.Format(time.RFC3339Nano)
+		}
+		return s, nil
+	case int64, uint64, time.Duration, *big.Rat, []byte, TCustomValue:
+		return formatValue(v), nil
+	case *regexp.Regexp:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("value %v has unsupported type %T", v, v)
+}
+
+// Returns true if s is t datetime value formatted using f datetime format
+// without loss of precision (s is parsed back to t)
+func (f *TDateTimeFormat) isExact(s string, t time.Time) bool {
+	if f == nil {
+		return true
+	}
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	p, err := time.ParseInLocation(f.Layout, s, loc)
+	return err == nil && p.Equal(t)
+}
+
+// Returns connection with e edge type name, min and max ratio, inMin
+// and inMax ratio of incoming connections and bidir bidirectionality in
+// the form which is used within template-file
+func marshalConnection(e string, min, max, inMin, inMax int, bidir bool) mConnection {
+	res := mConnection{
+		Edge:          e,
+		Ratio:         mRatio{min, max},
+		Bidirectional: bidir,
+	}
+	if inMin != 0 || inMax != INF {
+		res.Incoming = &mRatio{inMin, inMax}
+	}
+	return res
+}
+
+// Sorts cs connections by edge type names
+func sortConnections(cs []mConnection) {
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Edge < cs[j].Edge
+	})
+}
+
+// Returns names of ls labels and all labels inherited by them (directly
+// or transitively)
+func (t TemplateHolder) inheritedLabels(ls []string) map[string]bool {
+	res := make(map[string]bool)
+	var walk func(ls []string)
+	walk = func(ls []string) {
+		for _, l := range ls {
+			if res[l] {
+				continue
+			}
+			res[l] = true
+			if label, ok := t.Labels[l]; ok {
+				walk(label.Labels)
+			}
+		}
+	}
+	walk(ls)
+	return res
+}
+
+// Serializes cs constraints; constraints which are defined within labels
+// with names within inherited are omitted
+func (t TemplateHolder) marshalConstraints(cs []*TConstraint, inherited map[string]bool) []string {
+	defined := make(map[string]bool)
+	for l := range inherited {
+		if label, ok := t.Labels[l]; ok {
+			for _, c := range label.Constraints {
+				defined[c.Expr] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(cs))
+	for _, c := range cs {
+		if !defined[c.Expr] {
+			res = append(res, c.Expr)
+		}
+	}
+	return res
+}
+
+// Returns true if p property is defined within any of labels with names
+// within inherited
+func (t TemplateHolder) isLabelProperty(p *TProperty, inherited map[string]bool) bool {
+	for l := range inherited {
+		label, ok := t.Labels[l]
+		if !ok {
+			continue
+		}
+		if v, ok := label.Props[p.Key]; ok && v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if connection between m main node and s subject node
+// using e edge is inherited from connection of their labels
+func (t TemplateHolder) isLabelConnection(m, s, e string) bool {
+	if t.Nodes[m] == nil || t.Nodes[s] == nil {
+		return false
+	}
+	subjs := t.inheritedLabels(t.Nodes[s].Labels)
+	for ml := range t.inheritedLabels(t.Nodes[m].Labels) {
+		for sl := range subjs {
+			if t.LConns[ml][sl][e] != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,691 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"stg/template"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of "primitive" data types which are used within builder
+const (
+	Int      = "int"
+	Float    = "float"
+	String   = "string"
+	Bool     = "bool"
+	DateTime = "datetime"
+	Int64    = "int64"
+	Uint     = "uint"
+	Duration = "duration"
+	Date     = "date"
+	UUID     = "uuid"
+	Bytes    = "bytes"
+)
+
+// Returns name of decimal data type with precision (maximum amount of
+// digits) and scale (amount of digits after decimal point); 0 precision
+// means that decimal values aren't restricted
+func Decimal(precision, scale int) string {
+	if precision == 0 {
+		return "decimal"
+	}
+	return fmt.Sprintf("decimal(%d,%d)", precision, scale)
+}
+
+// Returns name of array data type with values of val data type (which
+// may be any data type including nested arrays and maps)
+func Array(val string) string {
+	return "array<" + val + ">"
+}
+
+// Returns name of map data type with keys of key "primitive" data type
+// and values of val data type (which may be any data type including
+// nested arrays and maps)
+func Map(key, val string) string {
+	return "map<" + key + "," + val + ">"
+}
+
+// Type that represents option of property definition (optionality
+// definitions, default value or restriction) within builder
+type PropOption func(p *bProperty, r *bRestrictions)
+
+// Marks property as not required
+func Optional() PropOption {
+	return func(p *bProperty, _ *bRestrictions) {
+		required := false
+		p.BufRequired = &required
+	}
+}
+
+// Marks property as required; it's useful to redefine optionality of
+// property of named data type
+func Required() PropOption {
+	return func(p *bProperty, _ *bRestrictions) {
+		required := true
+		p.BufRequired = &required
+	}
+}
+
+// Marks property as nullable
+func Nullable() PropOption {
+	return func(p *bProperty, _ *bRestrictions) {
+		nullable := true
+		p.BufNullable = &nullable
+	}
+}
+
+// Marks property as unique among all nodes of according node type (or
+// with according label) within graph
+func Unique() PropOption {
+	return func(p *bProperty, _ *bRestrictions) {
+		p.BufUnique = true
+	}
+}
+
+// Sets default value of property
+func Default(v interface{}) PropOption {
+	return func(p *bProperty, _ *bRestrictions) {
+		d := toRawValue(v)
+		p.BufDefault = &d
+	}
+}
+
+// Appends vs exact values restrictions
+func Values(vs ...interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		for _, v := range vs {
+			r.BufValueRestr = append(r.BufValueRestr, toRawValue(v))
+		}
+	}
+}
+
+// Appends rs regexps restrictions
+func Regexps(rs ...string) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufRegexpRestr = append(r.BufRegexpRestr, rs...)
+	}
+}
+
+// Appends vs validators restrictions (names of registered validators)
+func Validators(vs ...string) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufValidators = append(r.BufValidators, vs...)
+	}
+}
+
+// Appends vs exact values restrictions of map keys
+func KeyValues(vs ...interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		for _, v := range vs {
+			r.BufKeyValueRestr = append(r.BufKeyValueRestr, toRawValue(v))
+		}
+	}
+}
+
+// Appends rs regexps restrictions of map keys
+func KeyRegexps(rs ...string) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufKeyRegexpRestr = append(r.BufKeyRegexpRestr, rs...)
+	}
+}
+
+// Sets inclusive lower and upper bounds restrictions
+func Range(min, max interface{}) PropOption {
+	return func(p *bProperty, r *bRestrictions) {
+		Min(min)(p, r)
+		Max(max)(p, r)
+	}
+}
+
+// Sets inclusive lower bound restriction
+func Min(v interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		raw := toRawValue(v)
+		r.BufMinRestr = &raw
+	}
+}
+
+// Sets inclusive upper bound restriction
+func Max(v interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		raw := toRawValue(v)
+		r.BufMaxRestr = &raw
+	}
+}
+
+// Sets exclusive lower bound restriction
+func ExclusiveMin(v interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		raw := toRawValue(v)
+		r.BufExclMinRestr = &raw
+	}
+}
+
+// Sets exclusive upper bound restriction
+func ExclusiveMax(v interface{}) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		raw := toRawValue(v)
+		r.BufExclMaxRestr = &raw
+	}
+}
+
+// Sets min and max length restrictions of string values
+func Length(min, max int) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufMinLength, r.BufMaxLength = &min, &max
+	}
+}
+
+// Sets min and max amount of array items restrictions
+func Items(min, max int) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufMinItems, r.BufMaxItems = &min, &max
+	}
+}
+
+// Sets unique array items restriction
+func UniqueItems() PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufUniqueItems = true
+	}
+}
+
+// Sets min and max amount of map entries restrictions
+func Entries(min, max int) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		r.BufMinEntries, r.BufMaxEntries = &min, &max
+	}
+}
+
+// Sets restrictions of "inner" values of nested arrays and maps; opts
+// which aren't restrictions are ignored
+func Inner(opts ...PropOption) PropOption {
+	return func(_ *bProperty, r *bRestrictions) {
+		if r.BufInner == nil {
+			r.BufInner = &bRestrictions{}
+		}
+		for _, opt := range opts {
+			opt(&bProperty{}, r.BufInner)
+		}
+	}
+}
+
+// Returns v value in the form which is used within template-file
+func toRawValue(v interface{}) string {
+	switch v := v.(type) {
+	case float32:
+		return toRawValue(float64(v))
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *big.Rat:
+		if v.IsInt() {
+			return v.RatString()
+		}
+		return strings.TrimRight(v.FloatString(32), "0")
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// Type that builds template from Go code instead of template-file;
+// definitions are checked the same way as definitions of template-file
+// at the end of building process
+type TemplateBuilder struct {
+	t bTemplate
+}
+
+// Creates and returns new TemplateBuilder-struct
+func NewTemplate() *TemplateBuilder {
+	return &TemplateBuilder{
+		t: bTemplate{
+			BufTypes:  make(map[string]bProperty),
+			BufLabels: make(map[string]bLabel),
+			BufNodes:  make(map[string]bNode),
+			BufEdges:  make(map[string]bEdge),
+		},
+	}
+}
+
+// Defines layout (RFC3339 if it's empty) and timezone (IANA timezone
+// name or UTC offset; may be empty) of datetime values of template and
+// returns template builder; datetime values of Go code are accepted
+// regardless of layout
+func (b *TemplateBuilder) DateTimeFormat(layout, timezone string) *TemplateBuilder {
+	b.t.BufSettings = &bSettings{
+		BufDateTime: &bDateTime{
+			BufLayout:   layout,
+			BufTimezone: timezone,
+		},
+	}
+	return b
+}
+
+// Defines named data type with name and typ data type and returns
+// template builder
+func (b *TemplateBuilder) Type(name, typ string, opts ...PropOption) *TemplateBuilder {
+	b.t.BufTypes[name] = newProperty(typ, opts)
+	return b
+}
+
+// Defines (or reopens already defined) label with name and labels
+// inherited by it and returns label builder
+func (b *TemplateBuilder) Label(name string, labels ...string) *LabelBuilder {
+	l := b.t.BufLabels[name]
+	l.BufLabels = append(l.BufLabels, labels...)
+	b.t.BufLabels[name] = l
+	return &LabelBuilder{b, name, ""}
+}
+
+// Defines (or reopens already defined) node with name and labels
+// embedded in it and returns node builder
+func (b *TemplateBuilder) Node(name string, labels ...string) *NodeBuilder {
+	n := b.t.BufNodes[name]
+	n.BufLabels = append(n.BufLabels, labels...)
+	b.t.BufNodes[name] = n
+	return &NodeBuilder{b, name, ""}
+}
+
+// Defines (or reopens already defined) edge with name and returns edge
+// builder
+func (b *TemplateBuilder) Edge(name string) *EdgeBuilder {
+	b.t.BufEdges[name] = b.t.BufEdges[name]
+	return &EdgeBuilder{b, name}
+}
+
+// Checks all definitions and returns result as TemplateHolder-struct;
+// if any error occurs doesn't interrupt checking and then returns error
+// which contains all occured errors (the same way as ParseTemplate does)
+func (b *TemplateBuilder) Build() (*template.TemplateHolder, error) {
+	if err := b.t.checkDefinitions(); err != nil {
+		return nil, err
+	}
+	return b.t.toActual()
+}
+
+// Type that builds label definition; all methods of template builder
+// may be used to continue building of template
+type LabelBuilder struct {
+	*TemplateBuilder
+	name     string
+	lastSubj string
+}
+
+// Defines property of label with key name and typ data type and returns
+// label builder
+func (b *LabelBuilder) Prop(key, typ string, opts ...PropOption) *LabelBuilder {
+	l := b.t.BufLabels[b.name]
+	if l.BufProps == nil {
+		l.BufProps = make(map[string]bProperty)
+	}
+	l.BufProps[key] = newProperty(typ, opts)
+	b.t.BufLabels[b.name] = l
+	return b
+}
+
+// Defines list of property keys which values must be unique in
+// combination among all nodes with label and returns label builder
+func (b *LabelBuilder) UniqueTogether(keys ...string) *LabelBuilder {
+	l := b.t.BufLabels[b.name]
+	l.BufUniqueTogether = append(l.BufUniqueTogether, keys)
+	b.t.BufLabels[b.name] = l
+	return b
+}
+
+// Defines expr constraint which relates several properties of label and
+// returns label builder
+func (b *LabelBuilder) Constraint(expr string) *LabelBuilder {
+	l := b.t.BufLabels[b.name]
+	l.BufConstraints = append(l.BufConstraints, expr)
+	b.t.BufLabels[b.name] = l
+	return b
+}
+
+// Defines connection of label with subj label using edge with min and
+// max ratio and returns label builder
+func (b *LabelBuilder) Connect(subj, edge string, min, max int) *LabelBuilder {
+	l := b.t.BufLabels[b.name]
+	if l.BufConns == nil {
+		l.BufConns = make(map[string][]bConnection)
+	}
+	l.BufConns[subj] = append(l.BufConns[subj], newConnection(edge, min, max))
+	b.t.BufLabels[b.name] = l
+	b.lastSubj = subj
+	return b
+}
+
+// Defines min and max ratio of incoming connections of subject label
+// within the last defined connection and returns label builder
+func (b *LabelBuilder) Incoming(min, max int) *LabelBuilder {
+	setIncoming(b.t.BufLabels[b.name].BufConns[b.lastSubj], min, max)
+	return b
+}
+
+// Marks the last defined connection as bidirectional and returns label
+// builder
+func (b *LabelBuilder) Bidirectional() *LabelBuilder {
+	setBidirectional(b.t.BufLabels[b.name].BufConns[b.lastSubj])
+	return b
+}
+
+// Marks the last defined connection as polymorphic (its ratios are counted
+// across ALL node types with according labels) and returns label builder
+func (b *LabelBuilder) Polymorphic() *LabelBuilder {
+	setPolymorphic(b.t.BufLabels[b.name].BufConns[b.lastSubj])
+	return b
+}
+
+// Narrows (or adds) property of edge with key name and typ data type
+// within the last defined connection and returns label builder
+func (b *LabelBuilder) EdgeProp(key, typ string, opts ...PropOption) *LabelBuilder {
+	setConnectionProp(b.t.BufLabels[b.name].BufConns[b.lastSubj], key, newProperty(typ, opts))
+	return b
+}
+
+// Type that builds node definition; all methods of template builder
+// may be used to continue building of template
+type NodeBuilder struct {
+	*TemplateBuilder
+	name     string
+	lastSubj string
+}
+
+// Defines property of node with key name and typ data type and returns
+// node builder
+func (b *NodeBuilder) Prop(key, typ string, opts ...PropOption) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	if n.BufProps == nil {
+		n.BufProps = make(map[string]bProperty)
+	}
+	n.BufProps[key] = newProperty(typ, opts)
+	b.t.BufNodes[b.name] = n
+	return b
+}
+
+// Defines list of property keys which values must be unique in
+// combination among all nodes of node type and returns node builder
+func (b *NodeBuilder) UniqueTogether(keys ...string) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	n.BufUniqueTogether = append(n.BufUniqueTogether, keys)
+	b.t.BufNodes[b.name] = n
+	return b
+}
+
+// Defines keys of properties which are used as identity of node within
+// graph and returns node builder
+func (b *NodeBuilder) Key(keys ...string) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	n.BufKey = keys
+	b.t.BufNodes[b.name] = n
+	return b
+}
+
+// Defines expr constraint which relates several properties of node and
+// returns node builder
+func (b *NodeBuilder) Constraint(expr string) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	n.BufConstraints = append(n.BufConstraints, expr)
+	b.t.BufNodes[b.name] = n
+	return b
+}
+
+// Defines condition of node which is met if ALL properties with keys of
+// ifs hold according values and returns node builder; requirements of
+// condition are defined by Require- and Restrict-methods
+func (b *NodeBuilder) When(ifs map[string]interface{}) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	n.BufWhen = append(n.BufWhen, newCondition(ifs))
+	b.t.BufNodes[b.name] = n
+	return b
+}
+
+// Makes properties with keys required within the last defined condition
+// and returns node builder
+func (b *NodeBuilder) Require(keys ...string) *NodeBuilder {
+	setConditionRequired(b.t.BufNodes[b.name].BufWhen, keys)
+	return b
+}
+
+// Defines additional restrictions (only restriction options are used) of
+// property with key name within the last defined condition and returns
+// node builder
+func (b *NodeBuilder) Restrict(key string, opts ...PropOption) *NodeBuilder {
+	setConditionProp(b.t.BufNodes[b.name].BufWhen, key, newProperty("", opts).BufRestrs)
+	return b
+}
+
+// Defines connection of node with subj node using edge with min and max
+// ratio and returns node builder
+func (b *NodeBuilder) Connect(subj, edge string, min, max int) *NodeBuilder {
+	n := b.t.BufNodes[b.name]
+	if n.BufConns == nil {
+		n.BufConns = make(map[string][]bConnection)
+	}
+	n.BufConns[subj] = append(n.BufConns[subj], newConnection(edge, min, max))
+	b.t.BufNodes[b.name] = n
+	b.lastSubj = subj
+	return b
+}
+
+// Defines min and max ratio of incoming connections of subject node
+// within the last defined connection and returns node builder
+func (b *NodeBuilder) Incoming(min, max int) *NodeBuilder {
+	setIncoming(b.t.BufNodes[b.name].BufConns[b.lastSubj], min, max)
+	return b
+}
+
+// Marks the last defined connection as bidirectional and returns node
+// builder
+func (b *NodeBuilder) Bidirectional() *NodeBuilder {
+	setBidirectional(b.t.BufNodes[b.name].BufConns[b.lastSubj])
+	return b
+}
+
+// Narrows (or adds) property of edge with key name and typ data type
+// within the last defined connection and returns node builder
+func (b *NodeBuilder) EdgeProp(key, typ string, opts ...PropOption) *NodeBuilder {
+	setConnectionProp(b.t.BufNodes[b.name].BufConns[b.lastSubj], key, newProperty(typ, opts))
+	return b
+}
+
+// Type that builds edge definition; all methods of template builder
+// may be used to continue building of template
+type EdgeBuilder struct {
+	*TemplateBuilder
+	name string
+}
+
+// Defines property of edge with key name and typ data type and returns
+// edge builder
+func (b *EdgeBuilder) Prop(key, typ string, opts ...PropOption) *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	if e.BufProps == nil {
+		e.BufProps = make(map[string]bProperty)
+	}
+	e.BufProps[key] = newProperty(typ, opts)
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Defines keys of properties which are used as identity of edge within
+// graph and returns edge builder
+func (b *EdgeBuilder) Key(keys ...string) *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufKey = keys
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Defines expr constraint which relates several properties of edge and
+// returns edge builder
+func (b *EdgeBuilder) Constraint(expr string) *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufConstraints = append(e.BufConstraints, expr)
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Defines condition of edge which is met if ALL properties with keys of
+// ifs hold according values and returns edge builder; requirements of
+// condition are defined by Require- and Restrict-methods
+func (b *EdgeBuilder) When(ifs map[string]interface{}) *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufWhen = append(e.BufWhen, newCondition(ifs))
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Makes properties with keys required within the last defined condition
+// and returns edge builder
+func (b *EdgeBuilder) Require(keys ...string) *EdgeBuilder {
+	setConditionRequired(b.t.BufEdges[b.name].BufWhen, keys)
+	return b
+}
+
+// Defines additional restrictions (only restriction options are used) of
+// property with key name within the last defined condition and returns
+// edge builder
+func (b *EdgeBuilder) Restrict(key string, opts ...PropOption) *EdgeBuilder {
+	setConditionProp(b.t.BufEdges[b.name].BufWhen, key, newProperty("", opts).BufRestrs)
+	return b
+}
+
+// Restricts subgraph formed by edges of this type to be acyclic and
+// returns edge builder
+func (b *EdgeBuilder) Acyclic() *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufAcyclic = true
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Restricts subgraph formed by edges of this type to be a tree and returns
+// edge builder
+func (b *EdgeBuilder) Tree() *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufTree = true
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Restricts subgraph formed by edges of this type to be connected and
+// returns edge builder
+func (b *EdgeBuilder) Connected() *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufConnected = true
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Restricts the longest path of subgraph formed by edges of this type to
+// n edges and returns edge builder
+func (b *EdgeBuilder) MaxDepth(n int) *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufMaxDepth = &n
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Marks edge as undirected and returns edge builder
+func (b *EdgeBuilder) Undirected() *EdgeBuilder {
+	e := b.t.BufEdges[b.name]
+	e.BufDirection = "undirected"
+	b.t.BufEdges[b.name] = e
+	return b
+}
+
+// Creates and returns bProperty with typ data type and opts options
+func newProperty(typ string, opts []PropOption) bProperty {
+	p := bProperty{BufType: typ}
+	for _, opt := range opts {
+		opt(&p, &p.BufRestrs)
+	}
+	return p
+}
+
+// Sets min and max ratio of incoming connections within the last of cs
+// connections; does nothing if cs is empty
+func setIncoming(cs []bConnection, min, max int) {
+	if len(cs) == 0 {
+		return
+	}
+	cs[len(cs)-1].BufIncoming = &bRatio{min, max}
+}
+
+// Marks the last of cs connections as bidirectional; does nothing if cs
+// is empty
+func setBidirectional(cs []bConnection) {
+	if len(cs) == 0 {
+		return
+	}
+	cs[len(cs)-1].BufBidirectional = true
+}
+
+// Marks the last of cs connections as polymorphic; does nothing if cs is
+// empty
+func setPolymorphic(cs []bConnection) {
+	if len(cs) == 0 {
+		return
+	}
+	cs[len(cs)-1].BufPolymorphic = true
+}
+
+// Sets p property with key name within the last of cs connections; does
+// nothing if cs is empty
+func setConnectionProp(cs []bConnection, key string, p bProperty) {
+	if len(cs) == 0 {
+		return
+	}
+	if cs[len(cs)-1].BufProps == nil {
+		cs[len(cs)-1].BufProps = make(map[string]bProperty)
+	}
+	cs[len(cs)-1].BufProps[key] = p
+}
+
+// Creates and returns bCondition which is met if ALL properties with keys
+// of ifs hold according values
+func newCondition(ifs map[string]interface{}) bCondition {
+	c := bCondition{BufIf: make(map[string]string, len(ifs))}
+	for k, v := range ifs {
+		c.BufIf[k] = toRawValue(v)
+	}
+	return c
+}
+
+// Makes properties with keys required within the last of cs conditions;
+// does nothing if cs is empty
+func setConditionRequired(cs []bCondition, keys []string) {
+	if len(cs) == 0 {
+		return
+	}
+	cs[len(cs)-1].BufThen.BufRequired = append(cs[len(cs)-1].BufThen.BufRequired, keys...)
+}
+
+// Sets r restrictions of property with key name within the last of cs
+// conditions; does nothing if cs is empty
+func setConditionProp(cs []bCondition, key string, r bRestrictions) {
+	if len(cs) == 0 {
+		return
+	}
+	if cs[len(cs)-1].BufThen.BufProps == nil {
+		cs[len(cs)-1].BufThen.BufProps = make(map[string]bConditionProperty)
+	}
+	cs[len(cs)-1].BufThen.BufProps[key] = bConditionProperty{r}
+}
+
+// Creates and returns bConnection using edge with min and max ratio
+func newConnection(edge string, min, max int) bConnection {
+	c := bConnection{BufEdge: edge}
+	c.BufRatio.Min = min
+	c.BufRatio.Max = max
+	return c
+}
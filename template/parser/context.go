@@ -19,11 +19,15 @@ type context struct {
 }
 
 // Context label type - contains type name, nodes (in which label
-// properties and connections are included) and properties
+// properties and connections are included), properties and names of
+// directly inherited labels
 type cLabel struct {
-	typ   string
-	props map[string]*template.TProperty
-	nodes map[string]*template.TNode
+	typ    string
+	props  map[string]*template.TProperty
+	nodes  map[string]*template.TNode
+	labels []string
+	unique [][]string
+	consts []*template.TConstraint
 }
 
 // Context connection type - represents bound between main node with
@@ -31,20 +35,30 @@ type cLabel struct {
 // Edge, which ALWAYS directed from main node to subject; contains main
 // node, edge, subject node and minimum and maximum possible amount of
 // connections between ONE unique main node and ANY amount of unique
-// subject nodes using edge
+// subject nodes using edge (and the same for incoming connections of
+// ONE unique subject node); res is template Label Connection-struct which
+// is filled in while export (so node connections expanded from polymorphic
+// connection may refer to it before export)
 type cLConnection struct {
 	main *cLabel
 	edge *template.TEdge
 	subj *cLabel
 
-	min int
-	max int
+	min           int
+	max           int
+	inMin         int
+	inMax         int
+	bidirectional bool
+	props         map[string]*template.TProperty
+	polymorphic   bool
+	res           *template.TLConnection
 }
 
 // Creates and returns new context-struct
 func newContext() *context {
 	c := &context{
 		res: &template.TemplateHolder{
+			Types: make(map[string]*template.TProperty),
 			Nodes: make(map[string]*template.TNode),
 			Edges: make(map[string]*template.TEdge),
 			Conns: make(map[string]map[string]map[string]*template.TConnection),
@@ -61,13 +75,17 @@ func newContext() *context {
 // ---------------------- ERRORS ---------------------- //
 
 // Appends e to list of occured errors
+//
+// Concurrent safe
 func (c *context) appendErr(e parseError) {
+	c.Lock()
+	defer c.Unlock()
 	c.errs = append(c.errs, e)
 }
 
 // Builds and returns error which consists of all occured
 // errors during parsing; returns nil if no errors occured
-func (c context) buildErr() error {
+func (c *context) buildErr() error {
 	if len(c.errs) == 0 {
 		return nil
 	}
@@ -80,13 +98,82 @@ func (c context) buildErr() error {
 	return fmt.Errorf("%s", res)
 }
 
+// ---------------------- EXPORT ---------------------- //
+
+// Inserts buffer Label-structs and buffer Connection-structs of labels
+// within result as template Label-structs and template Label
+// Connection-structs accordingly
+//
+// # Concurrent safe
+//
+// If relevant underlying data is absent - allocates it
+func (c *context) exportLabels() {
+	c.Lock()
+	defer c.Unlock()
+	if c.res == nil {
+		c.res = &template.TemplateHolder{}
+	}
+	c.res.Labels = make(map[string]*template.TLabel, len(c.ls))
+	for k, v := range c.ls {
+		c.res.Labels[k] = &template.TLabel{
+			Typ:    v.typ,
+			Labels: v.labels,
+			Props:  v.props,
+
+			UniqueTogether: v.unique,
+			Constraints:    v.consts,
+		}
+	}
+	c.res.LConns = make(map[string]map[string]map[string]*template.TLConnection, len(c.lcn))
+	for m, ss := range c.lcn {
+		c.res.LConns[m] = make(map[string]map[string]*template.TLConnection, len(ss))
+		for s, es := range ss {
+			c.res.LConns[m][s] = make(map[string]*template.TLConnection, len(es))
+			for e, v := range es {
+				res := v.res
+				if res == nil {
+					res = new(template.TLConnection)
+				}
+				*res = template.TLConnection{
+					Main:  c.res.Labels[v.main.typ],
+					Edge:  v.edge,
+					Subj:  c.res.Labels[v.subj.typ],
+					Min:   v.min,
+					Max:   v.max,
+					InMin: v.inMin,
+					InMax: v.inMax,
+
+					Bidirectional: v.bidirectional,
+					Props:         v.props,
+					Polymorphic:   v.polymorphic,
+				}
+				c.res.LConns[m][s][e] = res
+			}
+		}
+	}
+}
+
 // ---------------------- GETTERS ---------------------- //
 
+// Returns named Property-struct (with n named data type name) at
+// success; returns nil otherwise
+//
+// Concurrent safe
+func (c *context) namedType(n string) *template.TProperty {
+	c.Lock()
+	defer c.Unlock()
+	if c.res == nil {
+		return nil
+	}
+	v := c.res.Types[n]
+	return v
+}
+
 // Returns Node-struct (with n type name) at success; returns nil
 // otherwise
 //
 // Concurrent safe
-func (c context) node(n string) *template.TNode {
+func (c *context) node(n string) *template.TNode {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil {
@@ -100,7 +187,7 @@ func (c context) node(n string) *template.TNode {
 // type name at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) nodeProp(n, p string) *template.TProperty {
+func (c *context) nodeProp(n, p string) *template.TProperty {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil || c.res.Nodes[n] == nil {
@@ -112,7 +199,7 @@ func (c context) nodeProp(n, p string) *template.TProperty {
 
 // Returns slice of label names of node with n type name at success;
 // returns nil otherwise
-func (c context) nodeLabels(n string) []string {
+func (c *context) nodeLabels(n string) []string {
 	c.Lock()
 	defer c.Unlock()
 	v := c.nls[n]
@@ -123,7 +210,7 @@ func (c context) nodeLabels(n string) []string {
 // otherwise
 //
 // Concurrent safe
-func (c context) label(l string) *cLabel {
+func (c *context) label(l string) *cLabel {
 	c.Lock()
 	defer c.Unlock()
 	v := c.ls[l]
@@ -134,7 +221,7 @@ func (c context) label(l string) *cLabel {
 // type name at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) labelProp(l, p string) *template.TProperty {
+func (c *context) labelProp(l, p string) *template.TProperty {
 	c.Lock()
 	defer c.Unlock()
 	if c.ls[l] == nil {
@@ -144,11 +231,25 @@ func (c context) labelProp(l, p string) *template.TProperty {
 	return v
 }
 
+// Returns constraints of label with l type name at success; returns nil
+// otherwise
+//
+// Concurrent safe
+func (c *context) labelConstraints(l string) []*template.TConstraint {
+	c.Lock()
+	defer c.Unlock()
+	if c.ls[l] == nil {
+		return nil
+	}
+	v := c.ls[l].consts
+	return v
+}
+
 // Returns map of nodes (node names mapped to Node-structs) of label
 // with l type name at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) labelNodes(l string) map[string]*template.TNode {
+func (c *context) labelNodes(l string) map[string]*template.TNode {
 	c.Lock()
 	defer c.Unlock()
 	if c.ls[l] == nil {
@@ -162,7 +263,7 @@ func (c context) labelNodes(l string) map[string]*template.TNode {
 // otherwise
 //
 // Concurrent safe
-func (c context) edge(e string) *template.TEdge {
+func (c *context) edge(e string) *template.TEdge {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil {
@@ -176,7 +277,7 @@ func (c context) edge(e string) *template.TEdge {
 // type name at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) edgeProp(e, p string) *template.TProperty {
+func (c *context) edgeProp(e, p string) *template.TProperty {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil || c.res.Edges[e] == nil {
@@ -190,7 +291,7 @@ func (c context) edgeProp(e, p string) *template.TProperty {
 // m type name) at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) labelConnsByMain(m string) map[string]map[string]*cLConnection {
+func (c *context) labelConnsByMain(m string) map[string]map[string]*cLConnection {
 	c.Lock()
 	defer c.Unlock()
 	v := c.lcn[m]
@@ -202,7 +303,7 @@ func (c context) labelConnsByMain(m string) map[string]map[string]*cLConnection
 // nil otherwise
 //
 // Concurrent safe
-func (c context) labelConnsByMainSubj(m, s string) map[string]*cLConnection {
+func (c *context) labelConnsByMainSubj(m, s string) map[string]*cLConnection {
 	c.Lock()
 	defer c.Unlock()
 	v := c.lcn[m][s]
@@ -214,7 +315,7 @@ func (c context) labelConnsByMainSubj(m, s string) map[string]*cLConnection {
 // success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) labelConn(m, s, e string) *cLConnection {
+func (c *context) labelConn(m, s, e string) *cLConnection {
 	c.Lock()
 	defer c.Unlock()
 	v := c.lcn[m][s][e]
@@ -225,7 +326,7 @@ func (c context) labelConn(m, s, e string) *cLConnection {
 // type name) at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) nodeConnsByMain(m string) map[string]map[string]*template.TConnection {
+func (c *context) nodeConnsByMain(m string) map[string]map[string]*template.TConnection {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil {
@@ -240,7 +341,7 @@ func (c context) nodeConnsByMain(m string) map[string]map[string]*template.TConn
 // nil otherwise
 //
 // Concurrent safe
-func (c context) nodeConnsByMainSubj(m, s string) map[string]*template.TConnection {
+func (c *context) nodeConnsByMainSubj(m, s string) map[string]*template.TConnection {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil {
@@ -255,7 +356,7 @@ func (c context) nodeConnsByMainSubj(m, s string) map[string]*template.TConnecti
 // at success; returns nil otherwise
 //
 // Concurrent safe
-func (c context) nodeConn(m, s, e string) *template.TConnection {
+func (c *context) nodeConn(m, s, e string) *template.TConnection {
 	c.Lock()
 	defer c.Unlock()
 	if c.res == nil {
@@ -267,6 +368,24 @@ func (c context) nodeConn(m, s, e string) *template.TConnection {
 
 // ---------------------- SETTERS ---------------------- //
 
+// Sets prop Property-struct as named data type with n name within
+// context
+//
+// # Concurrent safe
+//
+// If relevant underlying data is absent - allocates it
+func (c *context) setNamedType(n string, prop *template.TProperty) {
+	c.Lock()
+	defer c.Unlock()
+	if c.res == nil {
+		c.res = &template.TemplateHolder{}
+	}
+	if c.res.Types == nil {
+		c.res.Types = make(map[string]*template.TProperty)
+	}
+	c.res.Types[n] = prop
+}
+
 // Sets node Node-struct with n type name within context
 //
 // Concurrent safe
@@ -362,6 +481,25 @@ func (c *context) setLabelProp(l, p string, prop *template.TProperty) {
 	c.ls[l].props[p] = prop
 }
 
+// Sets cs constraints of label with l type name within context
+//
+// # Concurrent safe
+//
+// If relevant underlying data is absent - allocates it
+func (c *context) setLabelConstraints(l string, cs []*template.TConstraint) {
+	c.Lock()
+	defer c.Unlock()
+	if c.ls == nil {
+		c.ls = make(map[string]*cLabel)
+	}
+	if c.ls[l] == nil {
+		c.ls[l] = &cLabel{
+			typ: l,
+		}
+	}
+	c.ls[l].consts = cs
+}
+
 // Sets node Node-struct with n type name within label (with l type name)
 // within context
 //
@@ -20,7 +20,8 @@ func TestNewContext(t *testing.T) {
 
 func TestAppendErr(t *testing.T) {
 	temp := &context{
-		errs: []parseError{},
+		errs:  []parseError{},
+		Mutex: new(sync.Mutex),
 	}
 	temp.appendErr(parseError{
 		Loc: "test0",
@@ -58,6 +59,37 @@ func TestBuildErr(t *testing.T) {
 	}
 }
 
+func TestNamedType(t *testing.T) {
+	temp := &context{
+		res: &template.TemplateHolder{
+			Types: map[string]*template.TProperty{
+				"Test": {},
+			},
+		},
+		Mutex: new(sync.Mutex),
+	}
+	exp := temp.res.Types["Test"]
+	if temp.namedType("Test") != exp {
+		t.Error("Successive test-case is failed")
+	}
+	if temp.namedType("TestFail") != nil {
+		t.Error("Unsuccessive test-case is failed")
+	}
+	temp = &context{
+		res:   &template.TemplateHolder{},
+		Mutex: new(sync.Mutex),
+	}
+	if temp.namedType("Test") != nil {
+		t.Error("Unsuccessive test-case is failed")
+	}
+	temp = &context{
+		Mutex: new(sync.Mutex),
+	}
+	if temp.namedType("Test") != nil {
+		t.Error("Unsuccessive test-case is failed")
+	}
+}
+
 func TestNode(t *testing.T) {
 	temp := &context{
 		res: &template.TemplateHolder{
@@ -611,6 +643,34 @@ func TestNodeConn(t *testing.T) {
 	}
 }
 
+func TestSetNamedType(t *testing.T) {
+	temp := &context{
+		res: &template.TemplateHolder{
+			Types: map[string]*template.TProperty{},
+		},
+		Mutex: new(sync.Mutex),
+	}
+	obj := &template.TProperty{}
+	temp.setNamedType("Test", obj)
+	if temp.res.Types["Test"] != obj {
+		t.Error("Successive test-case is failed")
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			t.Error("Unsuccessive test-case is failed")
+		}
+	}()
+	temp = &context{
+		res:   &template.TemplateHolder{},
+		Mutex: new(sync.Mutex),
+	}
+	temp.setNamedType("Test", obj)
+	temp = &context{
+		Mutex: new(sync.Mutex),
+	}
+	temp.setNamedType("Test", obj)
+}
+
 func TestSetNode(t *testing.T) {
 	temp := &context{
 		res: &template.TemplateHolder{
@@ -0,0 +1,339 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"stg/template"
+	"strconv"
+)
+
+// Buffer type for representation of conflicting property definitions -
+// property with key name is defined differently within first and second
+// labels (or their inherited labels)
+type propConflict struct {
+	key    string
+	first  string
+	second string
+}
+
+// Returns names of all labels which are inherited (directly or
+// transitively) by label with l type name in depth-first order and
+// without duplicates; l itself and undefined labels are omitted, cyclic
+// inheritance doesn't cause endless recursion
+func labelAncestors(c *context, l string) []string {
+	res := make([]string, 0)
+	visited := map[string]bool{l: true}
+	var walk func(l string)
+	walk = func(l string) {
+		cl := c.label(l)
+		if cl == nil {
+			return
+		}
+		for _, p := range cl.labels {
+			if visited[p] || c.label(p) == nil {
+				continue
+			}
+			visited[p] = true
+			res = append(res, p)
+			walk(p)
+		}
+	}
+	walk(l)
+	return res
+}
+
+// Returns chain of label names which starts from label with l type name,
+// goes through its parent label with p type name and returns back to l;
+// returns nil if such chain doesn't exist
+func labelCycle(c *context, l, p string) []string {
+	visited := make(map[string]bool)
+	var walk func(cur string, path []string) []string
+	walk = func(cur string, path []string) []string {
+		path = append(path, cur)
+		if cur == l {
+			return path
+		}
+		if visited[cur] {
+			return nil
+		}
+		visited[cur] = true
+		cl := c.label(cur)
+		if cl == nil {
+			return nil
+		}
+		for _, next := range cl.labels {
+			if res := walk(next, path); res != nil {
+				return res
+			}
+		}
+		return nil
+	}
+	return walk(p, []string{l})
+}
+
+// Merges properties of labels with ls type names (including properties
+// of labels inherited by them) and returns them with list of conflicting
+// property definitions between ls labels; labels inherited by the same
+// label don't conflict with each other - closer label overrides further
+// one, so only conflicts between ls labels are detected
+func mergeLabelProps(c *context, ls []string) (map[string]*template.TProperty, []propConflict) {
+	return mergeLabelPropsVisited(c, ls, make(map[string]bool))
+}
+
+// Does the same as mergeLabelProps, but skips labels from visited (which
+// protects from endless recursion in case of cyclic inheritance)
+func mergeLabelPropsVisited(c *context, ls []string, visited map[string]bool) (map[string]*template.TProperty, []propConflict) {
+	res := make(map[string]*template.TProperty)
+	origins := make(map[string]string)
+	conflicts := make([]propConflict, 0)
+	for _, l := range ls {
+		if isInheritedByAny(c, l, ls) {
+			// label's descendant within ls already contains its properties
+			continue
+		}
+		cl := c.label(l)
+		if cl == nil || visited[l] {
+			continue
+		}
+		visited[l] = true
+		props, _ := mergeLabelPropsVisited(c, cl.labels, visited)
+		delete(visited, l)
+		for k, v := range cl.props {
+			// label properties overwrite inherited properties
+			props[k] = v
+		}
+
+		for k, v := range props {
+			if p, ok := res[k]; ok {
+				if p != v && !reflect.DeepEqual(p, v) {
+					conflicts = append(conflicts, propConflict{k, origins[k], l})
+				}
+				continue
+			}
+			res[k] = v
+			origins[k] = l
+		}
+	}
+	return res, conflicts
+}
+
+// Returns true if label with l type name is inherited (directly or
+// transitively) by any other label from ls
+func isInheritedByAny(c *context, l string, ls []string) bool {
+	for _, other := range ls {
+		if other == l {
+			continue
+		}
+		for _, a := range labelAncestors(c, other) {
+			if a == l {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Checks that each of lists of property keys within unique of entity
+// (label or node accordingly to entityType) is not empty and refers to
+// defined properties (defined checks it) only; don't interrupts on error
+// occurences and writes them into the error-list within context
+func checkUniqueTogether(c *context, n nesting, entityType string, unique [][]string, defined func(k string) bool) {
+	name := n[len(n)-1]
+	for i, ks := range unique {
+		if len(ks) == 0 {
+			e := parseError{
+				append(n, "unique_together", strconv.Itoa(i+1)).String(),
+				"list of unique properties can't be empty",
+			}
+			c.appendErr(e)
+		}
+		for j, k := range ks {
+			if !defined(k) {
+				e := parseError{
+					append(n, "unique_together", strconv.Itoa(i+1), strconv.Itoa(j+1)).String(),
+					fmt.Sprintf("%s %q has undefined property %q to make it unique", entityType, name, k),
+				}
+				c.appendErr(e)
+			}
+		}
+	}
+}
+
+// Checks that each of key property keys of entity (edge or node accordingly
+// to entityType) refers to defined (get returns it) and required property;
+// don't interrupts on error occurences and writes them into the error-list
+// within context
+func checkKey(c *context, n nesting, entityType string, key []string, get func(k string) *template.TProperty) {
+	name := n[len(n)-1]
+	for i, k := range key {
+		p := get(k)
+		switch {
+		case p == nil:
+			e := parseError{
+				append(n, "key", strconv.Itoa(i+1)).String(),
+				fmt.Sprintf("%s %q has undefined property %q to use it as key", entityType, name, k),
+			}
+			c.appendErr(e)
+		case !p.Required:
+			e := parseError{
+				append(n, "key", strconv.Itoa(i+1)).String(),
+				fmt.Sprintf("key property %q of %s %q can't be optional", k, entityType, name),
+			}
+			c.appendErr(e)
+		}
+	}
+}
+
+// Compiles exprs constraints of entity against ps properties and returns
+// them; don't interrupts on error occurences and writes them into the
+// error-list within context
+func compileConstraints(c *context, n nesting, exprs []string, ps map[string]*template.TProperty) []*template.TConstraint {
+	res := make([]*template.TConstraint, 0, len(exprs))
+	for i, expr := range exprs {
+		v, err := template.NewConstraint(expr, ps)
+		if err != nil {
+			e := parseError{
+				append(n, "constraints", strconv.Itoa(i+1)).String(),
+				fmt.Sprintf("wrong constraint %q: %s", expr, err.Error()),
+			}
+			c.appendErr(e)
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// Compiles constraints of ls labels against properties of node (labels'
+// properties may be overwritten by node) and returns them; constraints
+// which are already defined within node are omitted; don't interrupts on
+// error occurences and writes them into the error-list within context
+func labelConstraints(c *context, n nesting, ls []string, node *template.TNode) []*template.TConstraint {
+	res := make([]*template.TConstraint, 0)
+	defined := make(map[string]bool)
+	for _, v := range node.Constraints {
+		defined[v.Expr] = true
+	}
+	for _, l := range ls {
+		for _, lc := range c.labelConstraints(l) {
+			if defined[lc.Expr] {
+				continue
+			}
+			defined[lc.Expr] = true
+			v, err := template.NewConstraint(lc.Expr, node.Props)
+			if err != nil {
+				e := parseError{
+					append(n, "labels").String(),
+					fmt.Sprintf("wrong constraint %q of %q label: %s", lc.Expr, l, err.Error()),
+				}
+				c.appendErr(e)
+				continue
+			}
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Compiles cs conditions of entity (of entityType) against ps properties
+// and returns them; don't interrupts on error occurences and writes them
+// into the error-list within context
+func compileConditions(c *context, n nesting, entityType string, cs []bCondition, ps map[string]*template.TProperty) []*template.TCondition {
+	entity := n[len(n)-1]
+	res := make([]*template.TCondition, 0, len(cs))
+	for i, bc := range cs {
+		loc := append(append(nesting{}, n...), "when", strconv.Itoa(i+1))
+		actual := &template.TCondition{
+			If:    make(map[string]interface{}, len(bc.BufIf)),
+			Props: make(map[string]*template.TProperty),
+		}
+		if len(bc.BufIf) == 0 {
+			e := parseError{
+				append(loc, "if").String(),
+				"condition can't be empty",
+			}
+			c.appendErr(e)
+		}
+		for k, v := range bc.BufIf {
+			p := ps[k]
+			if p == nil {
+				e := parseError{
+					append(loc, "if", k).String(),
+					fmt.Sprintf("%s %q has undefined property %q to use it within condition", entityType, entity, k),
+				}
+				c.appendErr(e)
+				continue
+			}
+			if p.Typ == template.TArray || p.Typ == template.TMap {
+				e := parseError{
+					append(loc, "if", k).String(),
+					fmt.Sprintf("property %q of %q data type can't be used within condition", k, p.Typ),
+				}
+				c.appendErr(e)
+				continue
+			}
+			mut := getMutationTool(p.Typ.String(), template.TValue, p.DateTime)
+			if !mut.check(v) {
+				e := parseError{
+					append(loc, "if", k).String(),
+					fmt.Sprintf("condition value %q doesn't match %q data type", v, p.Typ),
+				}
+				c.appendErr(e)
+				continue
+			}
+			val, err := mut.mutate(v)
+			if err != nil {
+				e := parseError{
+					append(loc, "if", k).String(),
+					err.Error(),
+				}
+				c.appendErr(e)
+				continue
+			}
+			actual.If[k] = val
+		}
+
+		if len(bc.BufThen.BufRequired) == 0 && len(bc.BufThen.BufProps) == 0 {
+			e := parseError{
+				append(loc, "then").String(),
+				"condition doesn't have any requirements",
+			}
+			c.appendErr(e)
+		}
+		// conditional property is a copy of property with conditional restrictions only
+		get := func(k string) *template.TProperty {
+			if actual.Props[k] == nil && ps[k] != nil {
+				actual.Props[k] = toBareProperty(ps[k])
+				actual.Props[k].Required = false
+			}
+			return actual.Props[k]
+		}
+		for j, k := range bc.BufThen.BufRequired {
+			p := get(k)
+			if p == nil {
+				e := parseError{
+					append(loc, "then", "required", strconv.Itoa(j+1)).String(),
+					fmt.Sprintf("%s %q has undefined property %q to require it", entityType, entity, k),
+				}
+				c.appendErr(e)
+				continue
+			}
+			p.Required = true
+		}
+		for k, v := range bc.BufThen.BufProps {
+			p := get(k)
+			if p == nil {
+				e := parseError{
+					append(loc, "then", "properties", k).String(),
+					fmt.Sprintf("%s %q has undefined property %q to restrict it", entityType, entity, k),
+				}
+				c.appendErr(e)
+				continue
+			}
+			v.BufRestrs.nesting = append(append(nesting{}, loc...), "then", "properties", k, "restrictions")
+			v.BufRestrs.apply(c, p)
+		}
+		res = append(res, actual)
+	}
+	return res
+}
@@ -2,11 +2,16 @@ package parser
 
 import (
 	"io"
+	"io/fs"
+	"path"
 
 	"fmt"
+	"reflect"
 	"stg/template"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,16 +22,15 @@ import (
 // and then returns error which contains all occured errors during parsing
 //
 // WARNING: template dont allow some graph design practices, as:
-//   - maps nesting (which should be handled by making a new node/edge that
-//     contains nested map etc.)
-//   - arrays nesting (for the same reason as above)
-//   - nesting maps within arrays and vise versa (for the same reason as above)
+//   - maps and arrays nesting is allowed ONLY in recursive notation of data
+//     type - "array<value type>" or "map<key type,value type>" (for example,
+//     "array<map<string,int>>")
 //   - map's and array's values (and keys in case of maps) can contain only the
-//     same PRIMITIVE (int, string, etc) data types (for reasons of go data
-//     types compatibility)
-//   - labels can't nest within each other (which may lead to endless data-nesting
-//     and implicit property- and connection-definitions which may cause hard-to-find
-//     definition conflicts)
+//     same data types (for reasons of go data types compatibility); map's keys
+//     can be only PRIMITIVE (int, string, etc) data types
+//   - labels can inherit each other, but inheritance can't be cyclic and
+//     unrelated labels of the same node (or label) can't define the same
+//     property differently
 func ParseTemplate(file io.Reader) (*template.TemplateHolder, error) {
 	b, err := io.ReadAll(file)
 	if err != nil {
@@ -36,31 +40,186 @@ func ParseTemplate(file io.Reader) (*template.TemplateHolder, error) {
 		}
 	}
 
+	t, err := unmarshalTemplate(b, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.BufInclude) != 0 {
+		return nil, parseError{
+			nesting{"include"}.String(),
+			"includes can't be resolved without file system, use ParseTemplateFS instead",
+		}
+	}
+	if err := t.checkDefinitions(); err != nil {
+		return nil, err
+	}
+
+	templ, err := t.toActual()
+	if err != nil {
+		return nil, err
+	}
+	return templ, nil
+}
+
+// Parses template-file with root path within fsys file system and all
+// template-files included by it (using include-field, which contains
+// paths relative to the including file) and returns result as
+// TemplateHolder-struct; named data types, labels, nodes and edges of
+// all files are merged together, so they can refer to each other; if
+// any error occurs doesn't interrupt parsing and then returns error
+// which contains all occured errors during parsing (with names of
+// according files)
+//
+// WARNING: the same limitations as for ParseTemplate are applied; in
+// addition type names can't be duplicated within different files
+func ParseTemplateFS(fsys fs.FS, root string) (*template.TemplateHolder, error) {
+	c := newContext()
+	t := &bTemplate{
+		BufTypes:  make(map[string]bProperty),
+		BufLabels: make(map[string]bLabel),
+		BufNodes:  make(map[string]bNode),
+		BufEdges:  make(map[string]bEdge),
+	}
+	origins := map[string]map[string]string{
+		"settings": make(map[string]string),
+		"types":    make(map[string]string),
+		"labels":   make(map[string]string),
+		"nodes":    make(map[string]string),
+		"edges":    make(map[string]string),
+	}
+	t.include(c, fsys, path.Clean(root), nil, make(map[string]bool), origins)
+	if e := c.buildErr(); e != nil {
+		return nil, e
+	}
+	if err := t.checkDefinitions(); err != nil {
+		return nil, err
+	}
+
+	templ, err := t.toActual()
+	if err != nil {
+		return nil, err
+	}
+	return templ, nil
+}
+
+// Unmarshals b template-file into bTemplate-struct; n is used as
+// location of possible error
+func unmarshalTemplate(b []byte, n nesting) (*bTemplate, error) {
 	t := new(bTemplate)
-	err = yaml.UnmarshalStrict(b, t)
-	switch {
-	case err != nil:
+	if err := yaml.UnmarshalStrict(b, t); err != nil {
 		return nil, parseError{
-			"template",
+			n.String(),
 			".yaml parsing error: " + err.Error(),
 		}
-	case len(t.BufEdges) == 0:
-		return nil, parseError{
+	}
+	return t, nil
+}
+
+// Returns error if template doesn't contain mandatory definitions;
+// returns nil otherwise
+func (bt bTemplate) checkDefinitions() error {
+	switch {
+	case len(bt.BufEdges) == 0:
+		return parseError{
 			"template",
 			"there is no any edge definition",
 		}
-	case len(t.BufNodes) == 0:
-		return nil, parseError{
+	case len(bt.BufNodes) == 0:
+		return parseError{
 			"template",
 			"there is no any node definition",
 		}
 	}
+	return nil
+}
 
-	templ, err := t.toActual()
+// Reads template-file with file path within fsys file system and merges
+// its definitions (and definitions of all files included by it) into bt;
+// already visited files are skipped; origins contains names of files
+// where named data types, labels, nodes and edges were defined (to detect
+// duplicates); loc is a location of the include directive; don't
+// interrupts on error occurences and writes them into the error-list
+// within context
+func (bt *bTemplate) include(c *context, fsys fs.FS, file string, loc nesting,
+	visited map[string]bool, origins map[string]map[string]string) {
+	if visited[file] {
+		return
+	}
+	visited[file] = true
+
+	b, err := fs.ReadFile(fsys, file)
 	if err != nil {
-		return nil, err
+		e := parseError{
+			loc.String(),
+			"can't read file: " + err.Error(),
+		}
+		c.appendErr(e)
+		return
+	}
+	t, err := unmarshalTemplate(b, nesting{file})
+	if err != nil {
+		c.appendErr(err.(parseError))
+		return
+	}
+
+	// every definition remembers file where it's defined, so it's used
+	// within locations of errors
+	dup := func(section, k string) bool {
+		if f, ok := origins[section][k]; ok {
+			e := parseError{
+				nesting{file, section, k}.String(),
+				fmt.Sprintf("%q type name is already defined within %q file", k, f),
+			}
+			c.appendErr(e)
+			return true
+		}
+		origins[section][k] = file
+		return false
+	}
+	if t.BufSettings != nil && t.BufSettings.BufDateTime != nil {
+		if f, ok := origins["settings"]["datetime"]; ok {
+			e := parseError{
+				nesting{file, "settings", "datetime"}.String(),
+				fmt.Sprintf("datetime settings are already defined within %q file", f),
+			}
+			c.appendErr(e)
+		} else {
+			origins["settings"]["datetime"] = file
+			bt.BufSettings = &bSettings{
+				BufDateTime: t.BufSettings.BufDateTime,
+				nesting:     nesting{file},
+			}
+		}
+	}
+	for k, v := range t.BufTypes {
+		if !dup("types", k) {
+			v.nesting = nesting{file}
+			bt.BufTypes[k] = v
+		}
+	}
+	for k, v := range t.BufLabels {
+		if !dup("labels", k) {
+			v.nesting = nesting{file}
+			bt.BufLabels[k] = v
+		}
+	}
+	for k, v := range t.BufNodes {
+		if !dup("nodes", k) {
+			v.nesting = nesting{file}
+			bt.BufNodes[k] = v
+		}
+	}
+	for k, v := range t.BufEdges {
+		if !dup("edges", k) {
+			v.nesting = nesting{file}
+			bt.BufEdges[k] = v
+		}
+	}
+
+	for i, v := range t.BufInclude {
+		inc := path.Join(path.Dir(file), v)
+		bt.include(c, fsys, inc, nesting{file, "include", strconv.Itoa(i + 1)}, visited, origins)
 	}
-	return templ, nil
 }
 
 // Concurrently mutates bTemplate to actual Template-struct and return
@@ -69,7 +228,23 @@ func ParseTemplate(file io.Reader) (*template.TemplateHolder, error) {
 func (bt bTemplate) toActual() (*template.TemplateHolder, error) {
 	c := newContext()
 
+	// parses settings which are used while parsing of all other definitions
+	if bt.BufSettings != nil {
+		bs := *bt.BufSettings
+		bs.nesting = append(bs.nesting, "settings")
+		bs.toActual(c)
+	}
 	done := new(sync.WaitGroup)
+	// parses and transforms named data types
+	done.Add(len(bt.BufTypes))
+	for k, v := range bt.BufTypes {
+		go func(k string, v bProperty) {
+			defer done.Done()
+			v.nesting = append(v.nesting, "types", k)
+			v.toActual(c)
+		}(k, v)
+	}
+	done.Wait()
 	// parses and transforms edges with their properties
 	done.Add(len(bt.BufEdges))
 	for k, v := range bt.BufEdges {
@@ -90,12 +265,14 @@ func (bt bTemplate) toActual() (*template.TemplateHolder, error) {
 		}(k, v)
 	}
 	done.Wait()
-	// maps already parsed labels with each other using already parsed edges
+	// checks inheritance of already parsed labels; then maps them with each
+	// other using already parsed edges
 	done.Add(len(bt.BufLabels))
 	for k, v := range bt.BufLabels {
 		go func(k string, v bLabel) {
 			defer done.Done()
 			v.nesting = append(v.nesting, "labels", k)
+			v.checkInheritance(c)
 			v.mapConnections(c)
 		}(k, v)
 	}
@@ -124,6 +301,8 @@ func (bt bTemplate) toActual() (*template.TemplateHolder, error) {
 		}(k, v)
 	}
 	done.Wait()
+	// keeps labels and their connections as is within result
+	c.exportLabels()
 
 	if e := c.buildErr(); e != nil {
 		return nil, e
@@ -131,14 +310,83 @@ func (bt bTemplate) toActual() (*template.TemplateHolder, error) {
 	return c.res, nil
 }
 
+// Mutates bSettings to actual settings of template and inserts them to the
+// c; omitted layout is considered as RFC3339; don't interrupts on error
+// occurences and writes them into the error-list within context
+//
+// WARNING: dont call this func concurrently with parsing of any other
+// definitions, because they use settings
+func (bs bSettings) toActual(c *context) {
+	if bs.BufDateTime == nil {
+		return
+	}
+	res := &template.TDateTimeFormat{
+		Layout: time.RFC3339,
+	}
+	if l := bs.BufDateTime.BufLayout; l != "" {
+		if !isDateTimeLayout(l) {
+			e := parseError{
+				append(bs.nesting, "datetime", "layout").String(),
+				fmt.Sprintf("layout %q doesn't contain any datetime element", l),
+			}
+			c.appendErr(e)
+		}
+		res.Layout = l
+	}
+	if tz := bs.BufDateTime.BufTimezone; tz != "" {
+		loc, err := toLocation(tz)
+		if err != nil {
+			e := parseError{
+				append(bs.nesting, "datetime", "timezone").String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		res.Location = loc
+	}
+	c.res.DateTime = res
+}
+
 // Concurrently mutates bEdge to actual template Edge-struct and inserts
 // it to the c; don't interrupts on error occurences and writes them into
 // the error-list within context
 func (be bEdge) toActual(c *context) {
 	name := be.nesting[len(be.nesting)-1]
 	actual := &template.TEdge{
-		Typ:   name,
-		Props: make(map[string]*template.TProperty),
+		Typ:       name,
+		Props:     make(map[string]*template.TProperty),
+		Key:       be.BufKey,
+		Acyclic:   be.BufAcyclic,
+		Tree:      be.BufTree,
+		Connected: be.BufConnected,
+	}
+	switch be.BufDirection {
+	case "", "directed":
+	case "undirected":
+		actual.Undirected = true
+	default:
+		e := parseError{
+			append(be.nesting, "direction").String(),
+			fmt.Sprintf("unknown edge direction %q (\"directed\" or \"undirected\" expected)", be.BufDirection),
+		}
+		c.appendErr(e)
+	}
+	if be.BufMaxDepth != nil {
+		actual.MaxDepth = *be.BufMaxDepth
+		if *be.BufMaxDepth < 1 {
+			e := parseError{
+				append(be.nesting, "max_depth").String(),
+				fmt.Sprintf("max_depth should be greater than 0, not %d", *be.BufMaxDepth),
+			}
+			c.appendErr(e)
+		}
+		if actual.Undirected {
+			e := parseError{
+				append(be.nesting, "max_depth").String(),
+				"max_depth can't be defined for undirected edge",
+			}
+			c.appendErr(e)
+		}
 	}
 	c.setEdge(name, actual)
 
@@ -152,6 +400,12 @@ func (be bEdge) toActual(c *context) {
 		}(k, v)
 	}
 	done.Wait()
+
+	checkKey(c, be.nesting, "edge", be.BufKey, func(k string) *template.TProperty {
+		return c.edgeProp(name, k)
+	})
+	actual.Constraints = compileConstraints(c, be.nesting, be.BufConstraints, actual.Props)
+	actual.When = compileConditions(c, be.nesting, "edge", be.BufWhen, actual.Props)
 }
 
 // Concurrently mutates bLabel to buffer Label-struct (which then used to
@@ -160,9 +414,11 @@ func (be bEdge) toActual(c *context) {
 func (bl bLabel) toActual(c *context) {
 	name := bl.nesting[len(bl.nesting)-1]
 	actual := &cLabel{
-		typ:   name,
-		props: make(map[string]*template.TProperty),
-		nodes: make(map[string]*template.TNode),
+		typ:    name,
+		props:  make(map[string]*template.TProperty),
+		nodes:  make(map[string]*template.TNode),
+		labels: bl.BufLabels,
+		unique: bl.BufUniqueTogether,
 	}
 	c.setLabel(name, actual)
 
@@ -178,6 +434,59 @@ func (bl bLabel) toActual(c *context) {
 	done.Wait()
 }
 
+// Checks that labels inherited by bLabel are defined, that inheritance
+// isn't cyclic, that inherited labels don't define the same properties
+// differently and that unique_together lists and constraints refer to
+// defined properties; don't interrupts on error occurences and writes them
+// into the error-list within context
+//
+// WARNING: dont call this func until all buffer Label-structs were
+// inserted inside context (e.g. according toActual()-method were executed)
+func (bl bLabel) checkInheritance(c *context) {
+	name := bl.nesting[len(bl.nesting)-1]
+	for i, k := range bl.BufLabels {
+		loc := append(bl.nesting, "labels", strconv.Itoa(i+1)).String()
+		if c.label(k) == nil {
+			e := parseError{
+				loc,
+				fmt.Sprintf("%q label has undefined label %q to inherit it", name, k),
+			}
+			c.appendErr(e)
+			continue
+		}
+		if cycle := labelCycle(c, name, k); cycle != nil {
+			e := parseError{
+				loc,
+				fmt.Sprintf("%q label has cyclic inheritance %q", name, strings.Join(cycle, " -> ")),
+			}
+			c.appendErr(e)
+		}
+	}
+
+	props, conflicts := mergeLabelProps(c, bl.BufLabels)
+	checkUniqueTogether(c, bl.nesting, "label", bl.BufUniqueTogether, func(k string) bool {
+		return c.labelProp(name, k) != nil || props[k] != nil
+	})
+	if cl := c.label(name); cl != nil {
+		for k, v := range cl.props {
+			// label properties overwrite inherited properties
+			props[k] = v
+		}
+		c.setLabelConstraints(name, compileConstraints(c, bl.nesting, bl.BufConstraints, props))
+	}
+	for _, v := range conflicts {
+		if c.labelProp(name, v.key) != nil {
+			// label's own property overwrites conflicting inherited properties
+			continue
+		}
+		e := parseError{
+			append(bl.nesting, "labels").String(),
+			fmt.Sprintf("property %q is defined differently within inherited %q and %q labels", v.key, v.first, v.second),
+		}
+		c.appendErr(e)
+	}
+}
+
 // Concurrently maps connections between labels which stored within bLabel
 // and inserts them as buffer Connection-structs (which then used to create
 // additional actual Connection-structs) to the context; don't interrupts
@@ -212,8 +521,12 @@ func (bl bLabel) mapConnections(c *context) {
 func (bn bNode) toActual(c *context) {
 	name := bn.nesting[len(bn.nesting)-1]
 	actual := &template.TNode{
-		Typ:   name,
-		Props: make(map[string]*template.TProperty),
+		Typ:    name,
+		Labels: bn.BufLabels,
+		Props:  make(map[string]*template.TProperty),
+
+		UniqueTogether: bn.BufUniqueTogether,
+		Key:            bn.BufKey,
 	}
 	c.setNode(name, actual)
 
@@ -238,19 +551,34 @@ func (bn bNode) toActual(c *context) {
 					fmt.Sprintf("%q node has undefined label %q to attach it to node type", name, k),
 				}
 				c.appendErr(e)
-			} else {
-				c.setNodeLabel(name, k)
-				c.setLabelNode(k, name, actual)
 			}
 		}(i, k)
 	}
 	done.Wait()
+
+	// attaches labels with all their inherited labels to node type, so
+	// connections of inherited labels are also applied to node type
+	attached := make(map[string]bool)
+	for _, k := range bn.BufLabels {
+		if c.label(k) == nil {
+			continue
+		}
+		for _, l := range append([]string{k}, labelAncestors(c, k)...) {
+			if attached[l] {
+				continue
+			}
+			attached[l] = true
+			c.setNodeLabel(name, l)
+			c.setLabelNode(l, name, actual)
+		}
+	}
 }
 
 // Concurrently enriches according template Node-struct with data from
 // according tamplate Label-structs (both of them detected by bNode) -
-// all within context; don't interrupts on error occurences and writes
-// them into the error-list within context
+// all within context - and checks that unique_together lists, key,
+// constraints and conditions refer to defined properties; don't interrupts
+// on error occurences and writes them into the error-list within context
 //
 // WARNING: dont call this func until according Label- and Node-struct
 // were inserted inside context (e.g. according Node's toActual()-method
@@ -264,19 +592,41 @@ func (bn bNode) enrichByLabels(c *context) {
 	actual := c.node(name)
 	labels := c.nodeLabels(name)
 
+	// merges labels properties (including inherited ones) with main node
+	// properties
+	props, conflicts := mergeLabelProps(c, bn.BufLabels)
+	for k, v := range props {
+		if c.nodeProp(name, k) == nil {
+			// protects nodes properties from overwriting by labels properties
+			c.setNodeProp(name, k, v)
+		}
+	}
+	checkUniqueTogether(c, bn.nesting, "node", bn.BufUniqueTogether, func(k string) bool {
+		return c.nodeProp(name, k) != nil
+	})
+	checkKey(c, bn.nesting, "node", bn.BufKey, func(k string) *template.TProperty {
+		return c.nodeProp(name, k)
+	})
+	actual.Constraints = compileConstraints(c, bn.nesting, bn.BufConstraints, actual.Props)
+	actual.Constraints = append(actual.Constraints, labelConstraints(c, bn.nesting, labels, actual)...)
+	actual.When = compileConditions(c, bn.nesting, "node", bn.BufWhen, actual.Props)
+	for _, v := range conflicts {
+		if c.nodeProp(name, v.key) != props[v.key] {
+			// node's own property overwrites conflicting labels properties
+			continue
+		}
+		e := parseError{
+			append(bn.nesting, "labels").String(),
+			fmt.Sprintf("property %q is defined differently within %q and %q labels", v.key, v.first, v.second),
+		}
+		c.appendErr(e)
+	}
+
 	done := new(sync.WaitGroup)
 	done.Add(len(labels))
 	for _, mk := range labels {
 		go func(actual *template.TNode, name, mk string) {
 			defer done.Done()
-			// merges labels properties with main node properties
-			for k, v := range c.label(mk).props {
-				if c.nodeProp(name, k) == nil {
-					// protects nodes properties from overwriting by labels properties
-					c.setNodeProp(name, k, v)
-				}
-			}
-
 			// merges labels subject nodes and edges from ALL label connections with main node
 			for sk := range c.labelConnsByMain(mk) {
 				for ek := range c.labelConnsByMainSubj(mk, sk) {
@@ -287,11 +637,20 @@ func (bn bNode) enrichByLabels(c *context) {
 						if c.nodeConn(m, s, e) == nil {
 							// protects nodes connections from overwriting by labels connections
 							nConn := &template.TConnection{
-								Main: actual,
-								Edge: conn.edge,
-								Subj: sNode,
-								Min:  conn.min,
-								Max:  conn.max,
+								Main:  actual,
+								Edge:  conn.edge,
+								Subj:  sNode,
+								Min:   conn.min,
+								Max:   conn.max,
+								InMin: conn.inMin,
+								InMax: conn.inMax,
+
+								Bidirectional: conn.bidirectional,
+								Props:         conn.props,
+							}
+							if conn.polymorphic {
+								// ratios are validated at label level
+								nConn.Label = conn.res
 							}
 							c.setNodeConn(m, s, e, nConn)
 						}
@@ -326,20 +685,25 @@ func (bn bNode) mapConnections(c *context) {
 	done.Wait()
 }
 
-// Mutates bProperty to actual template Property-struct and inserts it
-// to the context; don't interrupts on error occurences and writes them
-// into the error-list within context
+// Mutates bProperty to actual template Property-struct, inserts it to
+// the context (as property of label, node or edge or as named type) and
+// returns it; property of connection isn't inserted to the context cus
+// it's kept within connection itself; if bProperty refers to named type -
+// inherits its definition; don't interrupts on error occurences and
+// writes them into the error-list within context
 //
 // WARNING: dont call this func until according Label-, Node- or Edge-struct
-// were inserted inside context (e.g. according toActual()-method were
-// executed), otherwise it will panic - "invalid memory address or nil
-// pointer dereference"
-func (bp bProperty) toActual(c *context) {
-	entityType := bp.nesting[len(bp.nesting)-4]
-	entity := bp.nesting[len(bp.nesting)-3]
+// and all named types were inserted inside context (e.g. according
+// toActual()-method were executed), otherwise it will panic - "invalid
+// memory address or nil pointer dereference"
+func (bp bProperty) toActual(c *context) *template.TProperty {
 	name := bp.nesting[len(bp.nesting)-1]
 	actual := &template.TProperty{
-		Key: name,
+		Key:      name,
+		Required: bp.BufRequired == nil || *bp.BufRequired,
+		Nullable: bp.BufNullable != nil && *bp.BufNullable,
+		Unique:   bp.BufUnique,
+		DateTime: c.res.DateTime,
 		ValRestrs: make([]*template.TRestriction, 0,
 			len(bp.BufRestrs.BufValueRestr)+len(bp.BufRestrs.BufRegexpRestr),
 		),
@@ -347,13 +711,54 @@ func (bp bProperty) toActual(c *context) {
 			len(bp.BufRestrs.BufKeyValueRestr)+len(bp.BufRestrs.BufKeyRegexpRestr),
 		),
 	}
-	switch entityType {
-	case "nodes":
-		c.setNodeProp(entity, name, actual)
-	case "edges":
-		c.setEdgeProp(entity, name, actual)
-	case "labels":
-		c.setLabelProp(entity, name, actual)
+	if bp.nesting[len(bp.nesting)-2] == "types" {
+		c.setNamedType(name, actual)
+		if bp.BufUnique {
+			e := parseError{
+				append(bp.nesting, "unique").String(),
+				"unique property can be defined only within nodes and labels",
+			}
+			c.appendErr(e)
+		}
+		if _, ok := template.LookupType(name); ok {
+			e := parseError{
+				bp.nesting.String(),
+				fmt.Sprintf("named data type %q conflicts with registered data type", name),
+			}
+			c.appendErr(e)
+		} else if _, err := toDataType(name); err == nil {
+			e := parseError{
+				bp.nesting.String(),
+				fmt.Sprintf("named data type %q conflicts with built-in data type", name),
+			}
+			c.appendErr(e)
+		}
+	} else {
+		entityType := bp.nesting[len(bp.nesting)-4]
+		entity := bp.nesting[len(bp.nesting)-3]
+		if len(bp.nesting) >= 5 && bp.nesting[len(bp.nesting)-5] == "connections" {
+			entityType = "connections"
+		}
+		if bp.BufUnique && entityType != "nodes" && entityType != "labels" {
+			e := parseError{
+				append(bp.nesting, "unique").String(),
+				"unique property can be defined only within nodes and labels",
+			}
+			c.appendErr(e)
+		}
+		switch entityType {
+		case "nodes":
+			c.setNodeProp(entity, name, actual)
+		case "edges":
+			c.setEdgeProp(entity, name, actual)
+		case "labels":
+			c.setLabelProp(entity, name, actual)
+		}
+		if named := c.namedType(bp.BufType); named != nil {
+			bp.inheritNamedType(c, actual, named)
+			bp.checkDefault(c, actual)
+			return actual
+		}
 	}
 
 	typs, err := toDataType(bp.BufType)
@@ -371,53 +776,111 @@ func (bp bProperty) toActual(c *context) {
 	if v := typs.Kt; v != template.TNull {
 		actual.KeyTyp = v
 	}
+	actual.Elem = typs.toInner(name, actual.DateTime)
+	actual.Precision, actual.Scale = typs.Prec, typs.Scale
+
+	if bp.BufDefault != nil && err == nil {
+		d, err := mutateDefault(typs, *bp.BufDefault, actual.DateTime)
+		if err != nil {
+			e := parseError{
+				append(bp.nesting, "default").String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		actual.Default = d
+	}
 
 	bp.BufRestrs.nesting = append(bp.nesting, "restrictions")
-	bp.BufRestrs.toActual(c)
+	bp.BufRestrs.apply(c, actual)
+	bp.checkDefault(c, actual)
+	return actual
 }
 
-// Mutates bRestrictions to actual template Restriction-struct and
-// inserts it to the context; don't interrupts on error occurences
-// and writes them into the error-list within context
-//
-// WARNING: dont call this func until according Property-struct were
-// inserted inside context (e.g. according toActual()-method were
-// executed), otherwise it will panic - "invalid memory address or nil
-// pointer dereference"
-func (br bRestrictions) toActual(c *context) {
-	entityType := br.nesting[len(br.nesting)-5]
-	entity := br.nesting[len(br.nesting)-4]
-	prop := br.nesting[len(br.nesting)-2]
-
-	var p *template.TProperty
-	var typs typeBuffer
-	switch entityType {
-	case "nodes":
-		entityType = "node"
-		p = c.nodeProp(entity, prop)
-	case "edges":
-		entityType = "edge"
-		p = c.edgeProp(entity, prop)
-	case "labels":
-		entityType = "label"
-		p = c.labelProp(entity, prop)
+// Checks if default value of p template Property-struct satisfies its
+// restrictions; default value isn't checked if it or any of restrictions
+// wasn't parsed; don't interrupts on error occurences and writes them into
+// the error-list within context
+func (bp bProperty) checkDefault(c *context, p *template.TProperty) {
+	if bp.BufDefault == nil || p.Default == nil {
+		return
+	}
+	for _, r := range p.ValRestrs {
+		if r == nil {
+			return
+		}
 	}
-	if p == nil {
+	if err := p.Evaluate(p.Default); err != nil {
 		e := parseError{
-			br.nesting.String(),
-			fmt.Sprintf("%s %q has undefined property %q to write restriction to it", entity, entityType, prop),
+			append(bp.nesting, "default").String(),
+			err.Error(),
 		}
 		c.appendErr(e)
-	} else {
-		typs = typeBuffer{
-			T:  p.Typ,
-			Vt: p.ValTyp,
-			Kt: p.KeyTyp,
+	}
+}
+
+// Fills in p template Property-struct by the data of named template
+// Property-struct - data type and restrictions are inherited as is and
+// optionality definitions and default value are inherited only if they
+// aren't redefined within bProperty; don't interrupts on error occurences
+// and writes them into the error-list within context
+func (bp bProperty) inheritNamedType(c *context, p, named *template.TProperty) {
+	p.Named = bp.BufType
+	p.Typ = named.Typ
+	p.ValTyp = named.ValTyp
+	p.KeyTyp = named.KeyTyp
+	p.Elem = named.Elem
+	p.Precision, p.Scale = named.Precision, named.Scale
+	// restrictions are copied, so the property doesn't share them with named type
+	p.ValRestrs = append(make([]*template.TRestriction, 0, len(named.ValRestrs)), named.ValRestrs...)
+	p.KeyRestrs = append(make([]*template.TRestriction, 0, len(named.KeyRestrs)), named.KeyRestrs...)
+	if bp.BufRequired == nil {
+		p.Required = named.Required
+	}
+	if bp.BufNullable == nil {
+		p.Nullable = named.Nullable
+	}
+	p.Default = named.Default
+
+	if bp.BufDefault != nil {
+		typs := typeBuffer{
+			T:  named.Typ,
+			Vt: named.ValTyp,
+			Kt: named.KeyTyp,
+		}
+		d, err := mutateDefault(typs, *bp.BufDefault, p.DateTime)
+		if err != nil {
+			e := parseError{
+				append(bp.nesting, "default").String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		p.Default = d
+	}
+	if !reflect.DeepEqual(bp.BufRestrs, bRestrictions{}) {
+		e := parseError{
+			append(bp.nesting, "restrictions").String(),
+			fmt.Sprintf("property of named data type %q can't redefine its restrictions", bp.BufType),
 		}
+		c.appendErr(e)
+	}
+}
+
+// Mutates bRestrictions to actual template Restriction-structs and
+// appends them to p template Property-struct; then recursively does
+// the same for restrictions of "inner" values of nested arrays and maps;
+// don't interrupts on error occurences and writes them into the
+// error-list within context
+func (br bRestrictions) apply(c *context, p *template.TProperty) {
+	typs := typeBuffer{
+		T:  p.Typ,
+		Vt: p.ValTyp,
+		Kt: p.KeyTyp,
 	}
 
 	for i, v := range br.BufValueRestr {
-		r, err := mutateRestr(typs, template.TValue, v)
+		r, err := mutateRestr(typs, template.TValue, v, p.DateTime)
 		if err != nil {
 			e := parseError{
 				append(br.nesting, "values", strconv.Itoa(i+1)).String(),
@@ -428,7 +891,7 @@ func (br bRestrictions) toActual(c *context) {
 		p.ValRestrs = append(p.ValRestrs, r)
 	}
 	for i, v := range br.BufRegexpRestr {
-		r, err := mutateRestr(typs, template.TRegExp, v)
+		r, err := mutateRestr(typs, template.TRegExp, v, p.DateTime)
 		if err != nil {
 			e := parseError{
 				append(br.nesting, "regexps", strconv.Itoa(i+1)).String(),
@@ -438,9 +901,87 @@ func (br bRestrictions) toActual(c *context) {
 		}
 		p.ValRestrs = append(p.ValRestrs, r)
 	}
+	for i, v := range br.BufValidators {
+		r, err := mutateValidatorRestr(typs, v)
+		if err != nil {
+			e := parseError{
+				append(br.nesting, "validators", strconv.Itoa(i+1)).String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		p.ValRestrs = append(p.ValRestrs, r)
+	}
+
+	bounds := []struct {
+		key string
+		rt  template.TRestrictionType
+		v   *string
+	}{
+		{"min", template.TMin, br.BufMinRestr},
+		{"max", template.TMax, br.BufMaxRestr},
+		{"exclusive_min", template.TExclusiveMin, br.BufExclMinRestr},
+		{"exclusive_max", template.TExclusiveMax, br.BufExclMaxRestr},
+	}
+	for _, b := range bounds {
+		if b.v == nil {
+			continue
+		}
+		r, err := mutateRestr(typs, b.rt, *b.v, p.DateTime)
+		if err != nil {
+			e := parseError{
+				append(br.nesting, b.key).String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		p.ValRestrs = append(p.ValRestrs, r)
+	}
+
+	var unique *int
+	if br.BufUniqueItems {
+		unique = new(int) // unique items restriction doesn't have any size
+	}
+	sizes := []struct {
+		key string
+		rt  template.TRestrictionType
+		v   *int
+	}{
+		{"min_length", template.TMinLength, br.BufMinLength},
+		{"max_length", template.TMaxLength, br.BufMaxLength},
+		{"min_items", template.TMinItems, br.BufMinItems},
+		{"max_items", template.TMaxItems, br.BufMaxItems},
+		{"min_entries", template.TMinEntries, br.BufMinEntries},
+		{"max_entries", template.TMaxEntries, br.BufMaxEntries},
+		{"unique_items", template.TUniqueItems, unique},
+	}
+	for _, sz := range sizes {
+		if sz.v == nil {
+			continue
+		}
+		r, err := mutateSizeRestr(typs, sz.rt, *sz.v)
+		if err != nil {
+			e := parseError{
+				append(br.nesting, sz.key).String(),
+				err.Error(),
+			}
+			c.appendErr(e)
+		}
+		p.ValRestrs = append(p.ValRestrs, r)
+	}
+	// checks min-max pairs of size restrictions for contradictions
+	for i := 0; i+1 < len(sizes); i += 2 {
+		if min, max := sizes[i].v, sizes[i+1].v; min != nil && max != nil && *min > *max {
+			e := parseError{
+				append(br.nesting, sizes[i].key).String(),
+				fmt.Sprintf("%q can't be greater than %q", sizes[i].key, sizes[i+1].key),
+			}
+			c.appendErr(e)
+		}
+	}
 
 	for i, v := range br.BufKeyValueRestr {
-		r, err := mutateRestr(typs, template.TKeyValue, v)
+		r, err := mutateRestr(typs, template.TKeyValue, v, p.DateTime)
 		if err != nil {
 			e := parseError{
 				append(br.nesting, "key_values", strconv.Itoa(i+1)).String(),
@@ -451,7 +992,7 @@ func (br bRestrictions) toActual(c *context) {
 		p.KeyRestrs = append(p.KeyRestrs, r)
 	}
 	for i, v := range br.BufKeyRegexpRestr {
-		r, err := mutateRestr(typs, template.TKeyRegExp, v)
+		r, err := mutateRestr(typs, template.TKeyRegExp, v, p.DateTime)
 		if err != nil {
 			e := parseError{
 				append(br.nesting, "key_regexps", strconv.Itoa(i+1)).String(),
@@ -461,6 +1002,20 @@ func (br bRestrictions) toActual(c *context) {
 		}
 		p.KeyRestrs = append(p.KeyRestrs, r)
 	}
+
+	if br.BufInner != nil {
+		inner := *br.BufInner
+		inner.nesting = append(append(nesting{}, br.nesting...), "inner")
+		if p.Elem == nil {
+			e := parseError{
+				inner.nesting.String(),
+				fmt.Sprintf("data type %q doesn't has nested arrays or maps to restrict their values", p.Typ),
+			}
+			c.appendErr(e)
+			return
+		}
+		inner.apply(c, p.Elem)
+	}
 }
 
 // Mutates bConnection to actual template Connection-struct and inserts
@@ -484,12 +1039,17 @@ func (bc bConnection) toActual(c *context) {
 	case "nodes":
 		mainType = "node"
 		m = c.node(main)
-		s = c.node(subj)
+		if n := c.node(subj); n != nil {
+			// protects from non-nil interface with nil pointer inside
+			s = n
+		}
 		match = c.nodeConn(main, subj, edge) != nil
 	case "labels":
 		mainType = "label"
 		m = c.label(main)
-		s = c.label(subj)
+		if l := c.label(subj); l != nil {
+			s = l
+		}
 		match = c.labelConn(main, subj, edge) != nil
 	}
 	e = c.edge(edge)
@@ -536,26 +1096,95 @@ func (bc bConnection) toActual(c *context) {
 		c.appendErr(e)
 	}
 
+	if bc.BufPolymorphic && mainType != "label" {
+		err = true
+		e := parseError{
+			append(bc.nesting, "polymorphic").String(),
+			"polymorphic connection can be defined only within labels",
+		}
+		c.appendErr(e)
+	}
+
+	if bc.BufBidirectional && e != nil && e.Undirected {
+		err = true
+		e := parseError{
+			append(bc.nesting, "bidirectional").String(),
+			fmt.Sprintf("connection through undirected edge %q can't be bidirectional", edge),
+		}
+		c.appendErr(e)
+	}
+
+	in := bRatio{0, template.INF}
+	if bc.BufIncoming != nil {
+		in = *bc.BufIncoming
+		if in.Min < 0 {
+			err = true
+			e := parseError{
+				append(bc.nesting, "incoming", "min").String(),
+				"\"min\" can't be less than 0",
+			}
+			c.appendErr(e)
+		}
+		if in.Max == 0 || in.Max < -1 {
+			err = true
+			e := parseError{
+				append(bc.nesting, "incoming", "max").String(),
+				"\"max\" can't be equal to 0 or be less than -1 (-1 is considered as positive infinity)",
+			}
+			c.appendErr(e)
+		}
+	}
+
+	var props map[string]*template.TProperty
+	if len(bc.BufProps) != 0 && e != nil {
+		props = make(map[string]*template.TProperty, len(bc.BufProps))
+		for k, v := range bc.BufProps {
+			v.nesting = append(bc.nesting, "properties", k)
+			p := v.toActual(c)
+			if ep, ok := e.Props[k]; ok && !isSameDataType(p, ep) {
+				err = true
+				e := parseError{
+					append(v.nesting, "type").String(),
+					fmt.Sprintf("property %q has different data type than the same property of %q edge", k, edge),
+				}
+				c.appendErr(e)
+			}
+			props[k] = p
+		}
+	}
+
 	if !err {
 		// its necessary to not insert any Connection-struct within c if any error
 		// occurs cus further reusing of this struct may cause hard-to-search errors
 		switch mainType {
 		case "node":
 			actual := &template.TConnection{
-				Main: m.(*template.TNode),
-				Edge: e,
-				Subj: s.(*template.TNode),
-				Min:  bc.BufRatio.Min,
-				Max:  bc.BufRatio.Max,
+				Main:  m.(*template.TNode),
+				Edge:  e,
+				Subj:  s.(*template.TNode),
+				Min:   bc.BufRatio.Min,
+				Max:   bc.BufRatio.Max,
+				InMin: in.Min,
+				InMax: in.Max,
+
+				Bidirectional: bc.BufBidirectional,
+				Props:         props,
 			}
 			c.setNodeConn(main, subj, edge, actual)
 		case "label":
 			actual := &cLConnection{
-				main: m.(*cLabel),
-				edge: e,
-				subj: s.(*cLabel),
-				min:  bc.BufRatio.Min,
-				max:  bc.BufRatio.Max,
+				main:  m.(*cLabel),
+				edge:  e,
+				subj:  s.(*cLabel),
+				min:   bc.BufRatio.Min,
+				max:   bc.BufRatio.Max,
+				inMin: in.Min,
+				inMax: in.Max,
+
+				bidirectional: bc.BufBidirectional,
+				props:         props,
+				polymorphic:   bc.BufPolymorphic,
+				res:           new(template.TLConnection),
 			}
 			c.setLabelConn(main, subj, edge, actual)
 		}
@@ -2,8 +2,13 @@ package parser
 
 import (
 	"fmt"
+	"reflect"
+	"stg/template"
+	"stg/validation"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 const (
@@ -160,3 +165,1504 @@ func TestParseTemplate(t *testing.T) {
 		t.Error("Unsuccessive test-case is failed")
 	}
 }
+
+func TestParseDefault(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        properties:
+            age:
+                type: int
+                required: false
+                default: 18
+                restrictions:
+                    max: 150
+            level:
+                type: int
+                default: 200
+                restrictions:
+                    max: 150
+            email:
+                type: Email
+                default: nobody
+            things:
+                type: array-string
+                default: thing
+            birth:
+                type: datetime
+                default: 1111-11-11
+edges:
+    friend:
+types:
+    Email:
+        type: string
+        restrictions:
+            regexps:
+                - ^.+@.+$
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | properties | things | default >> data type \"array\" can't has default value\n",
+		"template | nodes | Person | properties | birth | default >> default value \"1111-11-11\" doesn't match \"datetime\" data type\n",
+		"template | nodes | Person | properties | level | default >> \"level\"-property: \"200\" value doesn't satisfy \"max\" restriction \"150\"\n",
+		"template | nodes | Person | properties | email | default >> \"email\"-property: \"nobody\" value doesn't match neither restrictions\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed")
+		}
+	}
+	if strings.Contains(err.Error(), "age") {
+		t.Error("Successive test-case is failed")
+	}
+}
+
+func TestParseRangeRestrictions(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        properties:
+            age:
+                type: int
+                restrictions:
+                    min: 0
+                    exclusive_max: 150
+            name:
+                type: string
+                restrictions:
+                    max: Z
+edges:
+    friend:
+`)
+	_, err := ParseTemplate(temp)
+	exp := "template | nodes | Person | properties | name | restrictions | max >> data type \"string\" can't has range restrictions\n"
+	if err == nil || err.Error() != exp {
+		t.Error("Unsuccessive test-case is failed")
+	}
+}
+
+func TestParseSizeRestrictions(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        properties:
+            tags:
+                type: array-string
+                restrictions:
+                    min_items: 3
+                    max_items: 1
+                    min_entries: 1
+            age:
+                type: int
+                restrictions:
+                    max_length: 2
+edges:
+    friend:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | properties | tags | restrictions | min_items >> \"min_items\" can't be greater than \"max_items\"\n",
+		"template | nodes | Person | properties | tags | restrictions | min_entries >> data type \"array\" can't has entries restrictions\n",
+		"template | nodes | Person | properties | age | restrictions | max_length >> data type \"int\" can't has length restrictions\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+}
+
+func TestParseNestedType(t *testing.T) {
+	for typ, exp := range map[string]typeBuffer{
+		"array<int>": {T: template.TArray, Vt: template.TInt},
+		"map<string, array-int>": {T: template.TMap, Kt: template.TString, Vt: template.TArray,
+			Elem: &typeBuffer{T: template.TArray, Vt: template.TInt}},
+		"array<map<string,array<bool>>>": {T: template.TArray, Vt: template.TMap,
+			Elem: &typeBuffer{T: template.TMap, Kt: template.TString, Vt: template.TArray,
+				Elem: &typeBuffer{T: template.TArray, Vt: template.TBool}}},
+	} {
+		res, err := toDataType(typ)
+		if err != nil || !reflect.DeepEqual(res, exp) {
+			t.Errorf("Successive test-case %q is failed", typ)
+		}
+	}
+	for _, typ := range []string{
+		"array<>",
+		"map<string>",
+		"map<array<int>,int>",
+		"array<map<string,int>",
+	} {
+		if _, err := toDataType(typ); err == nil {
+			t.Errorf("Unsuccessive test-case %q is failed", typ)
+		}
+	}
+
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        properties:
+            scores:
+                type: array<array<int>>
+                restrictions:
+                    values:
+                        - 1
+            tags:
+                type: array<string>
+                restrictions:
+                    inner:
+                        min_items: 1
+edges:
+    friend:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | properties | scores | restrictions | values | 1 >> restriction \"1\" of nested \"array\" values should be defined within \"inner\" restrictions\n",
+		"template | nodes | Person | properties | tags | restrictions | inner >> data type \"array\" doesn't has nested arrays or maps to restrict their values\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+}
+
+func TestParseNamedType(t *testing.T) {
+	temp := strings.NewReader(`
+types:
+    Email:
+        type: string
+        required: false
+        restrictions:
+            regexps:
+                - ^.+@.+$
+    int:
+        type: int
+nodes:
+    Person:
+        properties:
+            email:
+                type: Email
+            work_email:
+                type: Email
+                required: true
+                restrictions:
+                    max_length: 32
+edges:
+    friend:
+`)
+	res, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | types | int >> named data type \"int\" conflicts with built-in data type\n",
+		"template | nodes | Person | properties | work_email | restrictions >> property of named data type \"Email\" can't redefine its restrictions\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+	if res != nil {
+		t.Error("Unsuccessive test-case is failed")
+	}
+
+	temp = strings.NewReader(`
+types:
+    Email:
+        type: string
+        required: false
+        nullable: true
+        restrictions:
+            regexps:
+                - ^.+@.+$
+nodes:
+    Person:
+        properties:
+            email:
+                type: Email
+            work_email:
+                type: Email
+                required: true
+                nullable: false
+edges:
+    friend:
+`)
+	res, err = ParseTemplate(temp)
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	email := res.Nodes["Person"].Props["email"]
+	workEmail := res.Nodes["Person"].Props["work_email"]
+	if email.Named != "Email" || email.Typ != template.TString || email.Required ||
+		!email.Nullable || len(email.ValRestrs) != 1 || !workEmail.Required || workEmail.Nullable {
+		t.Error("Successive test-case is failed")
+	}
+	// restrictions of property aren't shared with named type
+	email.ValRestrs[0] = nil
+	if res.Types["Email"].ValRestrs[0] == nil || workEmail.ValRestrs[0] == nil {
+		t.Error("Successive test-case is failed")
+	}
+}
+
+func TestParseLabelInheritance(t *testing.T) {
+	temp := strings.NewReader(`
+labels:
+    Auditable:
+        properties:
+            created:
+                type: datetime
+            name:
+                type: string
+        connections:
+            Auditable:
+                - edge: friend
+                  ratio:
+                      min: 0
+                      max: -1
+    Creature:
+        labels:
+            - Auditable
+        properties:
+            name:
+                type: string
+                required: false
+    Pet:
+        labels:
+            - Creature
+nodes:
+    Dog:
+        labels:
+            - Pet
+            - Auditable
+edges:
+    friend:
+`)
+	res, err := ParseTemplate(temp)
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	props := res.Nodes["Dog"].Props
+	if props["created"] == nil || props["name"] == nil || props["name"].Required {
+		t.Error("Successive test-case is failed")
+	}
+	if res.Conns["Dog"]["Dog"]["friend"] == nil {
+		t.Error("Successive test-case is failed")
+	}
+
+	temp = strings.NewReader(`
+labels:
+    A:
+        labels:
+            - B
+    B:
+        labels:
+            - A
+            - C
+    Named:
+        properties:
+            name:
+                type: string
+    Titled:
+        properties:
+            name:
+                type: int
+    Both:
+        labels:
+            - Named
+            - Titled
+nodes:
+    Person:
+        labels:
+            - Named
+            - Titled
+edges:
+    friend:
+`)
+	_, err = ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | labels | A | labels | 1 >> \"A\" label has cyclic inheritance \"A -> B -> A\"\n",
+		"template | labels | B | labels | 1 >> \"B\" label has cyclic inheritance \"B -> A -> B\"\n",
+		"template | labels | B | labels | 2 >> \"B\" label has undefined label \"C\" to inherit it\n",
+		"template | labels | Both | labels >> property \"name\" is defined differently within inherited \"Named\" and \"Titled\" labels\n",
+		"template | nodes | Person | labels >> property \"name\" is defined differently within \"Named\" and \"Titled\" labels\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+}
+
+func TestParseTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"root.yaml": {Data: []byte(`
+include:
+    - common/types.yaml
+    - people.yaml
+edges:
+    friend:
+`)},
+		"common/types.yaml": {Data: []byte(`
+include:
+    - ../people.yaml
+types:
+    Email:
+        type: string
+`)},
+		"people.yaml": {Data: []byte(`
+nodes:
+    Person:
+        properties:
+            email:
+                type: Email
+        connections:
+            Person:
+                - edge: friend
+                  ratio:
+                      min: 0
+                      max: -1
+`)},
+	}
+	res, err := ParseTemplateFS(fsys, "root.yaml")
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	if res.Nodes["Person"].Props["email"].Named != "Email" ||
+		res.Conns["Person"]["Person"]["friend"] == nil {
+		t.Error("Successive test-case is failed")
+	}
+
+	fsys["root.yaml"] = &fstest.MapFile{Data: []byte(`
+include:
+    - people.yaml
+    - missing.yaml
+nodes:
+    Person:
+        labels:
+            - Creature
+edges:
+    friend:
+`)}
+	_, err = ParseTemplateFS(fsys, "root.yaml")
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | people.yaml | nodes | Person >> \"Person\" type name is already defined within \"root.yaml\" file\n",
+		"template | root.yaml | include | 2 >> can't read file: ",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	delete(fsys, "people.yaml")
+	fsys["root.yaml"] = &fstest.MapFile{Data: []byte(`
+nodes:
+    Person:
+        labels:
+            - Creature
+edges:
+    friend:
+`)}
+	_, err = ParseTemplateFS(fsys, "root.yaml")
+	exp := "template | root.yaml | nodes | Person | labels | 1 >> \"Person\" node has undefined label \"Creature\" to attach it to node type\n"
+	if err == nil || err.Error() != exp {
+		t.Error("Unsuccessive test-case is failed")
+	}
+
+	_, err = ParseTemplate(strings.NewReader(`
+include:
+    - people.yaml
+`))
+	if err == nil || !strings.HasPrefix(err.Error(), "template | include >> ") {
+		t.Error("Unsuccessive test-case is failed")
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	temp := strings.NewReader(file + `
+types:
+    Email:
+        type: string
+        nullable: true
+        default: a@b.c
+        restrictions:
+            regexps:
+                - ^.+@.+$
+            max_length: 64
+`)
+	res, err := ParseTemplate(temp)
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	res.Nodes["Person"].Props["email"] = &template.TProperty{
+		Key: "email", Typ: template.TString, ValTyp: template.TString,
+		ValRestrs: res.Types["Email"].ValRestrs, KeyRestrs: res.Types["Email"].KeyRestrs,
+		Required: false, Nullable: true, Default: "x@y.z", Named: "Email",
+	}
+	res.Nodes["Person"].Props["work_email"] = &template.TProperty{
+		Key: "work_email", Typ: template.TString, ValTyp: template.TString,
+		ValRestrs: res.Types["Email"].ValRestrs, KeyRestrs: res.Types["Email"].KeyRestrs,
+		Required: true, Nullable: false, Default: "a@b.c", Named: "Email",
+	}
+	res.Nodes["Person"].Props["scores"] = &template.TProperty{
+		Key: "scores", Typ: template.TMap, KeyTyp: template.TString, ValTyp: template.TArray,
+		ValRestrs: []*template.TRestriction{
+			{Typ: template.TMap, RestrTyp: template.TMaxEntries, Restr: 3},
+		},
+		KeyRestrs: []*template.TRestriction{},
+		Required:  true,
+		Elem: &template.TProperty{
+			Key: "scores", Typ: template.TArray, ValTyp: template.TFloat,
+			ValRestrs: []*template.TRestriction{
+				{Typ: template.TFloat, RestrTyp: template.TMin, Restr: 1.0},
+				{Typ: template.TArray, RestrTyp: template.TUniqueItems, Restr: true},
+			},
+			KeyRestrs: []*template.TRestriction{},
+			Required:  true,
+		},
+	}
+
+	for _, expand := range []bool{false, true} {
+		marshal := template.Marshal
+		if expand {
+			// expanded template-file doesn't contain labels
+			marshal = template.MarshalExpanded
+			for _, v := range res.Nodes {
+				v.Labels = nil
+			}
+		}
+		b, err := marshal(*res)
+		if err != nil {
+			t.Fatal("Successive test-case is failed: " + err.Error())
+		}
+		parsed, err := ParseTemplate(strings.NewReader(string(b)))
+		if err != nil {
+			t.Fatal("Successive test-case is failed: " + err.Error())
+		}
+		if !reflect.DeepEqual(parsed.Nodes, res.Nodes) ||
+			!reflect.DeepEqual(parsed.Edges, res.Edges) ||
+			!reflect.DeepEqual(parsed.Conns, res.Conns) ||
+			!reflect.DeepEqual(parsed.Types, res.Types) {
+			t.Errorf("Successive test-case is failed:\n%s", b)
+		}
+		if !expand && (!reflect.DeepEqual(parsed.Labels, res.Labels) ||
+			!reflect.DeepEqual(parsed.LConns, res.LConns)) {
+			t.Errorf("Successive test-case is failed:\n%s", b)
+		}
+		again, _ := marshal(*parsed)
+		if string(again) != string(b) {
+			t.Error("Successive test-case is failed: serialization isn't canonical")
+		}
+	}
+
+	res, err = ParseTemplate(strings.NewReader(`
+labels:
+    Auditable:
+        properties:
+            created:
+                type: datetime
+        connections:
+            Auditable:
+                - edge: friend
+                  ratio:
+                      min: 1
+                      max: 3
+    Pet:
+        labels:
+            - Auditable
+nodes:
+    Dog:
+        labels:
+            - Pet
+        properties:
+            created:
+                type: datetime
+                required: false
+        connections:
+            Dog:
+                - edge: owner
+                  ratio:
+                      min: 0
+                      max: 1
+edges:
+    friend:
+    owner:
+`))
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	b, err := template.Marshal(*res)
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestTemplateBuilder(t *testing.T) {
+	_, err := NewTemplate().
+		Node("Person", "Creature").
+		Prop("age", Int, Range(0, 150.5), Length(1, 2)).
+		Prop("scores", Map(String, Array(Float)), Inner(Items(3, 1))).
+		Connect("Dog", "friend", 0, -1).
+		Edge("friend").
+		Build()
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | labels | 1 >> \"Person\" node has undefined label \"Creature\" to attach it to node type\n",
+		"template | nodes | Person | properties | age | restrictions | max >> restriction \"150.5\" doesn't match \"int\" data type\n",
+		"template | nodes | Person | properties | age | restrictions | min_length >> data type \"int\" can't has length restrictions\n",
+		"template | nodes | Person | properties | scores | restrictions | inner | min_items >> \"min_items\" can't be greater than \"max_items\"\n",
+		"template | nodes | Person | connections | Dog | 1 >> node \"Person\" has undefined subject \"node\" \"Dog\" to create connection\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	_, err = NewTemplate().Node("Person").Build()
+	if err == nil || err.Error() != "template >> there is no any edge definition" {
+		t.Error("Unsuccessive test-case is failed")
+	}
+
+	built, err := NewTemplate().
+		Node("Person").Prop("things", "array-string", Values("thing"), Regexps("^thi..")).
+		Edge("friend").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	parsed, _ := ParseTemplate(strings.NewReader(`
+nodes:
+    Person:
+        properties:
+            things:
+                type: array-string
+                restrictions:
+                    values:
+                        - thing
+                    regexps:
+                        - ^thi..
+edges:
+    friend:
+`))
+	if !reflect.DeepEqual(built, parsed) {
+		t.Error("Successive test-case is failed")
+	}
+}
+
+func TestParseIncoming(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        connections:
+            Pet:
+                - edge: owns
+                  ratio:
+                      min: 0
+                      max: -1
+                  incoming:
+                      min: -1
+                      max: 0
+    Pet:
+edges:
+    owns:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | connections | Pet | 1 | incoming | min >> \"min\" can't be less than 0\n",
+		"template | nodes | Person | connections | Pet | 1 | incoming | max >> \"max\" can't be equal to 0 or be less than -1 (-1 is considered as positive infinity)\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Label("Owner").Connect("Owned", "owns", 0, -1).Incoming(1, 1).
+		Label("Owned").
+		Node("Person", "Owner").
+		Node("Pet", "Owned").
+		Edge("owns").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	conn := res.Conns["Person"]["Pet"]["owns"]
+	if conn == nil || conn.InMin != 1 || conn.InMax != 1 {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        connections:
+            Person:
+                - edge: friend
+                  ratio:
+                      min: 0
+                      max: -1
+                  bidirectional: true
+edges:
+    friend:
+        direction: undirected
+    follows:
+        direction: sideways
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | edges | follows | direction >> unknown edge direction \"sideways\" (\"directed\" or \"undirected\" expected)\n",
+		"template | nodes | Person | connections | Person | 1 | bidirectional >> connection through undirected edge \"friend\" can't be bidirectional\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Label("Social").Connect("Social", "follows", 0, -1).Bidirectional().
+		Node("Person", "Social").Connect("Person", "friend", 0, -1).
+		Edge("friend").Undirected().
+		Edge("follows").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	if !res.Edges["friend"].Undirected || res.Edges["follows"].Undirected ||
+		!res.Conns["Person"]["Person"]["follows"].Bidirectional ||
+		res.Conns["Person"]["Person"]["friend"].Bidirectional {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseConnectionProperties(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        connections:
+            Org:
+                - edge: member_of
+                  ratio:
+                      min: 0
+                      max: -1
+                  properties:
+                      role:
+                          type: int
+    Org:
+edges:
+    member_of:
+        properties:
+            role:
+                type: string
+`)
+	_, err := ParseTemplate(temp)
+	exp := "template | nodes | Person | connections | Org | 1 | properties | role | type >> property \"role\" has different data type than the same property of \"member_of\" edge\n"
+	if err == nil || !strings.Contains(err.Error(), exp) {
+		t.Error("Unsuccessive test-case is failed: " + exp)
+	}
+
+	res, err := NewTemplate().
+		Label("Member").Connect("Group", "member_of", 0, -1).
+		EdgeProp("role", String, Values("admin", "user")).
+		Label("Group").
+		Node("Person", "Member").
+		Connect("Project", "member_of", 0, -1).
+		EdgeProp("role", String, Values("owner", "viewer")).
+		EdgeProp("since", Int, Optional()).
+		Node("Org", "Group").
+		Node("Project").
+		Edge("member_of").Prop("role", String).
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	org, prj := res.Conns["Person"]["Org"]["member_of"], res.Conns["Person"]["Project"]["member_of"]
+	if org == nil || len(org.Props) != 1 || prj == nil || len(prj.Props) != 2 ||
+		res.Edges["member_of"].Props["role"] == org.Props["role"] {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseUnique(t *testing.T) {
+	temp := strings.NewReader(`
+types:
+    Email:
+        type: string
+        unique: true
+nodes:
+    Person:
+        unique_together:
+            - [name, age]
+            - []
+        properties:
+            name:
+                type: string
+edges:
+    knows:
+        properties:
+            since:
+                type: int
+                unique: true
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | types | Email | unique >> unique property can be defined only within nodes and labels\n",
+		"template | edges | knows | properties | since | unique >> unique property can be defined only within nodes and labels\n",
+		"template | nodes | Person | unique_together | 1 | 2 >> node \"Person\" has undefined property \"age\" to make it unique\n",
+		"template | nodes | Person | unique_together | 2 >> list of unique properties can't be empty\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Label("Account").Prop("login", String, Unique()).
+		Node("Person", "Account").
+		Prop("first", String).
+		Prop("last", String).
+		UniqueTogether("first", "last").
+		Edge("knows").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	if !res.Nodes["Person"].Props["login"].Unique || len(res.Nodes["Person"].UniqueTogether) != 1 {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        key: [id, email]
+        properties:
+            email:
+                type: string
+                required: false
+edges:
+    knows:
+        key: [id]
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | key | 1 >> node \"Person\" has undefined property \"id\" to use it as key\n",
+		"template | nodes | Person | key | 2 >> key property \"email\" of node \"Person\" can't be optional\n",
+		"template | edges | knows | key | 1 >> edge \"knows\" has undefined property \"id\" to use it as key\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Label("Entity").Prop("id", Int).
+		Node("Person", "Entity").Key("id").
+		Edge("knows").Prop("id", Int).Key("id").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	keys := res.GraphKeys()
+	if !reflect.DeepEqual(keys.Nodes["Person"], []string{"id"}) || !reflect.DeepEqual(keys.Edges["knows"], []string{"id"}) {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	temp := strings.NewReader(`
+labels:
+    Named:
+        constraints:
+            - len(name) > 0
+        properties:
+            name:
+                type: string
+nodes:
+    Person:
+        labels:
+            - Named
+        constraints:
+            - age + name
+            - height > 0
+            - age
+        properties:
+            age:
+                type: int
+    Robot:
+        labels:
+            - Named
+        properties:
+            name:
+                type: int
+edges:
+    knows:
+        constraints:
+            - since < (1
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Person | constraints | 1 >> wrong constraint \"age + name\": operator \"+\" can't be applied to int and string\n",
+		"template | nodes | Person | constraints | 2 >> wrong constraint \"height > 0\": undefined property \"height\"\n",
+		"template | nodes | Person | constraints | 3 >> wrong constraint \"age\": expression should have bool result, not int\n",
+		"template | nodes | Robot | labels >> wrong constraint \"len(name) > 0\" of \"Named\" label: function \"len\" can't be applied to int\n",
+		"template | edges | knows | constraints | 1 >> wrong constraint \"since < (1\": unexpected end of expression\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Label("Named").Prop("name", String).Constraint("len(name) > 0").
+		Node("Event", "Named").Prop("start", DateTime).Prop("end", DateTime).Constraint("end > start").
+		Edge("pays").Prop("sum", Float).Prop("fee %", Float).Constraint("`fee %` < sum * 0.1").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	if len(res.Nodes["Event"].Constraints) != 2 || len(res.Edges["pays"].Constraints) != 1 {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Order:
+        when:
+            - if:
+                status: 1
+                items: a
+                missed: a
+              then:
+                required: [shipped_at, missed]
+                properties:
+                    tracking:
+                        restrictions:
+                            min: 1
+            - if:
+              then:
+        properties:
+            status:
+                type: int
+            items:
+                type: array-string
+            shipped_at:
+                type: datetime
+                required: false
+            tracking:
+                type: string
+                required: false
+edges:
+    pays:
+        when:
+            - if:
+                sum: many
+              then:
+                properties:
+                    fee: {}
+        properties:
+            sum:
+                type: int
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Order | when | 1 | if | items >> property \"items\" of \"array\" data type can't be used within condition\n",
+		"template | nodes | Order | when | 1 | if | missed >> node \"Order\" has undefined property \"missed\" to use it within condition\n",
+		"template | nodes | Order | when | 1 | then | required | 2 >> node \"Order\" has undefined property \"missed\" to require it\n",
+		"template | nodes | Order | when | 1 | then | properties | tracking | restrictions | min >> data type \"string\" can't has range restrictions\n",
+		"template | nodes | Order | when | 2 | if >> condition can't be empty\n",
+		"template | nodes | Order | when | 2 | then >> condition doesn't have any requirements\n",
+		"template | edges | pays | when | 1 | if | sum >> condition value \"many\" doesn't match \"int\" data type\n",
+		"template | edges | pays | when | 1 | then | properties | fee >> edge \"pays\" has undefined property \"fee\" to restrict it\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Node("Order").
+		Prop("status", String).
+		Prop("shipped_at", DateTime, Optional()).
+		Prop("tracking", String, Optional()).
+		When(map[string]interface{}{"status": "shipped"}).Require("shipped_at", "tracking").Restrict("tracking", Regexps("^[A-Z0-9]+$")).
+		When(map[string]interface{}{"status": "new"}).Restrict("tracking", Length(0, 0)).
+		Edge("pays").Prop("sum", Int).Prop("card", Bool).
+		When(map[string]interface{}{"card": true}).Restrict("sum", Max(1000)).
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	when := res.Nodes["Order"].When
+	if len(when) != 2 || when[0].If["status"] != "shipped" || !when[0].Props["shipped_at"].Required || when[1].Props["tracking"].Required {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+edges:
+    knows:
+        direction: undirected
+        max_depth: 2
+    reports_to:
+        tree: true
+        max_depth: 0
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | edges | knows | max_depth >> max_depth can't be defined for undirected edge\n",
+		"template | edges | reports_to | max_depth >> max_depth should be greater than 0, not 0\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Node("Person").Connect("Person", "reports_to", 0, 1).Connect("Person", "knows", 0, -1).
+		Edge("reports_to").Tree().MaxDepth(5).
+		Edge("knows").Undirected().Acyclic().Connected().
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	if e := res.Edges["reports_to"]; !e.Tree || e.MaxDepth != 5 || e.Acyclic {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParsePolymorphic(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Person:
+        connections:
+            Person:
+                - edge: knows
+                  ratio:
+                      min: 0
+                      max: 1
+                  polymorphic: true
+edges:
+    knows:
+`)
+	_, err := ParseTemplate(temp)
+	exp := "template | nodes | Person | connections | Person | 1 | polymorphic >> polymorphic connection can be defined only within labels\n"
+	if err == nil || !strings.Contains(err.Error(), exp) {
+		t.Error("Unsuccessive test-case is failed")
+	}
+
+	res, err := NewTemplate().
+		Label("Owner").Connect("Animal", "owns", 0, 3).Polymorphic().
+		Label("Animal").
+		Node("Human", "Owner").
+		Node("Dog", "Animal").
+		Node("Cat", "Animal").Connect("Cat", "owns", 0, 1).
+		Edge("owns").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	lc := res.LConns["Owner"]["Animal"]["owns"]
+	if !lc.Polymorphic || res.Conns["Human"]["Dog"]["owns"].Label != lc || res.Conns["Human"]["Cat"]["owns"].Label != lc {
+		t.Error("Successive test-case is failed")
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseNumbersAndDateTime(t *testing.T) {
+	temp := strings.NewReader(`
+settings:
+    datetime:
+        layout: layout
+        timezone: Mars/Olympus
+nodes:
+    Person:
+        properties:
+            age:
+                type: int
+                restrictions:
+                    values: [-1, +2, 99999999999999999999]
+            weight:
+                type: float
+                restrictions:
+                    values: [1e-3, -0.5, 1, NaN]
+            birth:
+                type: datetime
+                restrictions:
+                    min: 2023-01-01T10:00:00.5+02:00
+edges:
+    knows:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | settings | datetime | layout >> layout \"layout\" doesn't contain any datetime element\n",
+		"template | settings | datetime | timezone >> timezone \"Mars/Olympus\" is neither IANA timezone name nor UTC offset (+HH:MM)\n",
+		"template | nodes | Person | properties | age | restrictions | values | 3 >> value \"99999999999999999999\" is out of \"int\" data type range\n",
+		"template | nodes | Person | properties | weight | restrictions | values | 4 >> restriction \"NaN\" doesn't match \"float\" data type\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+	if strings.Count(err.Error(), "\n") != 4 {
+		t.Errorf("Unsuccessive test-case is failed:\n%s", err)
+	}
+
+	temp = strings.NewReader(`
+settings:
+    datetime:
+        layout: 2006-01-02 15:04
+        timezone: "+02:00"
+nodes:
+    Person:
+        properties:
+            birth:
+                type: datetime
+                default: 2023-01-01 10:00
+                restrictions:
+                    min: 2022-12-31T23:00:00Z
+            weight:
+                type: float
+                restrictions:
+                    min: 0
+                    values: [-1, 2.5]
+edges:
+    knows:
+`)
+	res, err := ParseTemplate(temp)
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	birth := res.Nodes["Person"].Props["birth"]
+	if d := birth.Default.(time.Time); !d.Equal(time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Successive test-case is failed: %s", d)
+	}
+	if birth.DateTime != res.DateTime || res.DateTime.Layout != "2006-01-02 15:04" {
+		t.Error("Successive test-case is failed")
+	}
+	for _, r := range res.Nodes["Person"].Props["weight"].ValRestrs {
+		if _, ok := r.Restr.(float64); !ok {
+			t.Errorf("Successive test-case is failed: %#v", r.Restr)
+		}
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+
+	res, err = NewTemplate().
+		DateTimeFormat("2006-01-02", "UTC").
+		Node("Person").
+		Prop("birth", DateTime, Min(time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)), Default("2001-01-01")).
+		Prop("balance", Int, Values(-1, 0)).
+		Prop("ratio", Float, Range(-1.5, 1e-3)).
+		Edge("knows").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	b, _ = template.Marshal(*res)
+	parsed, err = ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed.Nodes, res.Nodes) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+
+	// sub-second datetimes are kept by serialization with and without layout
+	for _, settings := range []string{"", `
+settings:
+    datetime:
+        layout: 2006-01-02 15:04`} {
+		res, err = ParseTemplate(strings.NewReader(settings + `
+nodes:
+    Person:
+        properties:
+            birth:
+                type: datetime
+                default: 2023-01-01T10:00:00.75Z
+                restrictions:
+                    min: 2023-01-01T10:00:00.5Z
+edges:
+    knows:
+`))
+		if err != nil {
+			t.Fatal("Successive test-case is failed: " + err.Error())
+		}
+		b, _ = template.Marshal(*res)
+		parsed, err = ParseTemplate(strings.NewReader(string(b)))
+		if err != nil || !reflect.DeepEqual(parsed, res) || !strings.Contains(string(b), "10:00:00.5Z") {
+			t.Errorf("Successive test-case is failed:\n%s", b)
+		}
+		before := validation.NewNode("Person", map[string]interface{}{
+			"birth": time.Date(2023, 1, 1, 10, 0, 0, 1e8, time.UTC),
+		})
+		if ok, _ := parsed.ValidateNode(before); ok {
+			t.Errorf("Successive test-case is failed: datetime before sub-second bound is valid:\n%s", b)
+		}
+	}
+}
+
+func TestParseExtendedTypes(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    Account:
+        properties:
+            id:
+                type: int64
+                restrictions:
+                    values: [-1, 9223372036854775808]
+            flags:
+                type: array-uint
+                restrictions:
+                    values: [+1, -1]
+            balance:
+                type: decimal(2,5)
+            rates:
+                type: map-decimal-int
+            blobs:
+                type: map<bytes,int>
+            timeout:
+                type: duration
+                restrictions:
+                    values: [1 hour]
+            opened:
+                type: date
+                default: 2023-13-01
+            token:
+                type: uuid
+                restrictions:
+                    min: 3F2504E0-4F89-11D3-9A0C-0305E82C3301
+            avatar:
+                type: bytes
+                restrictions:
+                    values: ["!!!"]
+edges:
+    transfer:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Account | properties | id | restrictions | values | 2 >> value \"9223372036854775808\" is out of \"int64\" data type range\n",
+		"template | nodes | Account | properties | flags | restrictions | values | 2 >> restriction \"-1\" doesn't match \"uint\" data type\n",
+		"template | nodes | Account | properties | balance | type >> data type \"decimal(2,5)\" should has precision greater than 0 and scale not greater than precision\n",
+		"template | nodes | Account | properties | rates | type >> data type \"map\" can't has keys of \"decimal\" data type\n",
+		"template | nodes | Account | properties | blobs | type >> data type \"map\" can't has keys of \"bytes\" data type\n",
+		"template | nodes | Account | properties | timeout | restrictions | values | 1 >> restriction \"1 hour\" doesn't match \"duration\" data type\n",
+		"template | nodes | Account | properties | opened | default >> default value \"2023-13-01\" doesn't match \"date\" data type\n",
+		"template | nodes | Account | properties | token | restrictions | min >> data type \"uuid\" can't has range restrictions\n",
+		"template | nodes | Account | properties | avatar | restrictions | values | 1 >> restriction \"!!!\" doesn't match \"bytes\" data type\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Node("Account").
+		Prop("id", Int64, Min(int64(-5))).
+		Prop("flags", Array(Uint), Values(uint(1), uint(2))).
+		Prop("balance", Decimal(10, 2), Default("0.50")).
+		Prop("limits", Map(String, Decimal(5, 1)), Max("1000.5")).
+		Prop("timeout", Duration, Max(time.Hour)).
+		Prop("opened", Date, Min(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))).
+		Prop("token", UUID, Values("3F2504E0-4F89-11D3-9A0C-0305E82C3301")).
+		Prop("avatar", Bytes, Values([]byte{1, 2, 3}), Length(0, 4)).
+		Edge("transfer").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	limits := res.Nodes["Account"].Props["limits"]
+	if limits.ValTyp != template.TDecimal || limits.Precision != 5 || limits.Scale != 1 {
+		t.Error("Successive test-case is failed")
+	}
+	if v := res.Nodes["Account"].Props["token"].ValRestrs[0].Restr; v != "3f2504e0-4f89-11d3-9a0c-0305e82c3301" {
+		t.Errorf("Successive test-case is failed: %v", v)
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+// Custom data type of levels - accepts "low", "mid" and "high" strings
+// which are ordered accordingly
+type levelType struct{}
+
+var levels = map[string]int{"low": 0, "mid": 1, "high": 2}
+
+func (levelType) Assert(v interface{}) (interface{}, bool) {
+	s, ok := v.(string)
+	_, found := levels[s]
+	return s, ok && found
+}
+
+func (levelType) Parse(s string) (interface{}, error) {
+	if _, ok := levels[s]; !ok {
+		return nil, fmt.Errorf("%q isn't level", s)
+	}
+	return s, nil
+}
+
+func (levelType) Compare(v1, v2 interface{}) int {
+	return levels[v1.(string)] - levels[v2.(string)]
+}
+
+func (levelType) Format(v interface{}) string {
+	return v.(string)
+}
+
+func init() {
+	if err := template.RegisterType("level", levelType{}); err != nil {
+		panic(err)
+	}
+	if err := template.RegisterValidator("lower", func(v interface{}) error {
+		if s, _ := v.(string); s != strings.ToLower(s) {
+			return fmt.Errorf("%q isn't in lower case", s)
+		}
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestParseCustomTypes(t *testing.T) {
+	temp := strings.NewReader(`
+types:
+    level:
+        type: string
+nodes:
+    Task:
+        properties:
+            priority:
+                type: string
+edges:
+    blocks:
+`)
+	_, err := ParseTemplate(temp)
+	if exp := "template | types | level >> named data type \"level\" conflicts with registered data type\n"; err == nil || !strings.Contains(err.Error(), exp) {
+		t.Error("Unsuccessive test-case is failed: " + exp)
+	}
+
+	temp = strings.NewReader(`
+nodes:
+    Task:
+        properties:
+            priority:
+                type: level
+                restrictions:
+                    values: [low, urgent]
+                    max_length: 4
+            escalation:
+                type: array<level>
+                default: high
+            owners:
+                type: map-level-string
+                restrictions:
+                    key_values: [top]
+edges:
+    blocks:
+`)
+	_, err = ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | Task | properties | priority | restrictions | values | 2 >> restriction \"urgent\" doesn't match \"level\" data type\n",
+		"template | nodes | Task | properties | priority | restrictions | max_length >> data type \"level\" can't has length restrictions\n",
+		"template | nodes | Task | properties | escalation | default >> data type \"array\" can't has default value\n",
+		"template | nodes | Task | properties | owners | restrictions | key_values | 1 >> restriction \"top\" doesn't match \"string\" data type\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Node("Task").
+		Prop("priority", "level", Range("low", "mid"), Default("mid")).
+		Prop("escalation", Array("level"), Values("mid", "high")).
+		Prop("owners", Map("level", String), KeyValues("high")).
+		Constraint("priority < escalation").
+		Edge("blocks").
+		Build()
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	if exp := "operator \"<\" can't be applied to level and array"; !strings.Contains(err.Error(), exp) {
+		t.Error("Unsuccessive test-case is failed: " + err.Error())
+	}
+
+	res, err = NewTemplate().
+		Node("Task").
+		Prop("priority", "level", Range("low", "mid"), Default("mid")).
+		Prop("deadline", "level", Optional()).
+		Prop("escalation", Array("level"), Values("mid", "high")).
+		Prop("owners", Map("level", String), KeyValues("high")).
+		Constraint("priority <= deadline").
+		Edge("blocks").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	priority := res.Nodes["Task"].Props["priority"]
+	if typ, _ := template.LookupType("level"); priority.Typ != typ || priority.Default != "mid" {
+		t.Errorf("Successive test-case is failed: %v", priority)
+	}
+	if v := priority.ValRestrs[1].Restr; v != (template.TCustomValue{Typ: priority.Typ, Val: "mid"}) {
+		t.Errorf("Successive test-case is failed: %v", v)
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
+
+func TestParseValidators(t *testing.T) {
+	temp := strings.NewReader(`
+nodes:
+    User:
+        properties:
+            login:
+                type: string
+                restrictions:
+                    validators: [lower, isoCountry]
+            tags:
+                type: array<array<string>>
+                restrictions:
+                    validators: [lower]
+            roles:
+                type: map<string,array<string>>
+                restrictions:
+                    inner:
+                        validators: [lower, luhn]
+edges:
+    follows:
+`)
+	_, err := ParseTemplate(temp)
+	if err == nil {
+		t.Fatal("Unsuccessive test-case is failed")
+	}
+	for _, exp := range []string{
+		"template | nodes | User | properties | login | restrictions | validators | 2 >> undefined validator \"isoCountry\"\n",
+		"template | nodes | User | properties | tags | restrictions | validators | 1 >> restriction \"lower\" of nested \"array\" values should be defined within \"inner\" restrictions\n",
+		"template | nodes | User | properties | roles | restrictions | inner | validators | 2 >> undefined validator \"luhn\"\n",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Error("Unsuccessive test-case is failed: " + exp)
+		}
+	}
+
+	res, err := NewTemplate().
+		Node("User").
+		Prop("login", String, Validators("lower"), Regexps("^[a-z]+$")).
+		Prop("roles", Map(String, Array(String)), Inner(Validators("lower"))).
+		Edge("follows").
+		Build()
+	if err != nil {
+		t.Fatal("Successive test-case is failed: " + err.Error())
+	}
+	login := res.Nodes["User"].Props["login"]
+	if r := login.ValRestrs[1]; r.RestrTyp != template.TValidator || r.Restr != "lower" {
+		t.Errorf("Successive test-case is failed: %v", r)
+	}
+	b, _ := template.Marshal(*res)
+	parsed, err := ParseTemplate(strings.NewReader(string(b)))
+	if err != nil || !reflect.DeepEqual(parsed, res) {
+		t.Errorf("Successive test-case is failed:\n%s", b)
+	}
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
 	"regexp"
 	"stg/template"
 	"strconv"
@@ -11,18 +13,88 @@ import (
 
 // regexps for validation of unprocessed (in string form) data types
 var (
-	intRe      = regexp.MustCompile(`^\d+$`).MatchString
-	floatRe    = regexp.MustCompile(`^\d+\.\d+$`).MatchString
-	datetimeRe = regexp.MustCompile(`^\d\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\dZ$`).MatchString
-	// string and bool data types don't need regexp
+	intRe  = regexp.MustCompile(`^[+-]?\d+$`).MatchString
+	uintRe = regexp.MustCompile(`^\+?\d+$`).MatchString
+	// string and bool data types don't need regexp; float, decimal, datetime,
+	// date, duration, uuid and bytes values are checked by parsing
+	decimalTypeRe = regexp.MustCompile(`^decimal\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)$`)
 )
 
 // Buffer type for representation of each unique data type
-// combination (mostrly for maps and arrays)
+// combination (mostrly for maps and arrays); Elem represents
+// "inner" values data type if they are also arrays or maps;
+// Prec and Scale are precision and scale of decimal values
 type typeBuffer struct {
-	T  template.TDataType
-	Vt template.TDataType
-	Kt template.TDataType
+	T     template.TDataType
+	Vt    template.TDataType
+	Kt    template.TDataType
+	Elem  *typeBuffer
+	Prec  int
+	Scale int
+}
+
+// Creates and returns chain of template Property-structs (with key
+// name and f datetime format) which describes "inner" values of nested
+// arrays and maps; returns nil if t doesn't describe nested arrays or maps
+func (t typeBuffer) toInner(key string, f *template.TDateTimeFormat) *template.TProperty {
+	if t.Elem == nil {
+		return nil
+	}
+	return &template.TProperty{
+		Key:       key,
+		Typ:       t.Elem.T,
+		ValTyp:    t.Elem.Vt,
+		KeyTyp:    t.Elem.Kt,
+		ValRestrs: make([]*template.TRestriction, 0),
+		KeyRestrs: make([]*template.TRestriction, 0),
+		Required:  true,
+		Elem:      t.Elem.toInner(key, f),
+		DateTime:  f,
+		Precision: t.Elem.Prec,
+		Scale:     t.Elem.Scale,
+	}
+}
+
+// Creates and returns copy of p template Property-struct (including chain
+// of "inner" values of nested arrays and maps) which has the same data
+// type and nullability, but doesn't have any restrictions
+func toBareProperty(p *template.TProperty) *template.TProperty {
+	if p == nil {
+		return nil
+	}
+	return &template.TProperty{
+		Key:       p.Key,
+		Typ:       p.Typ,
+		ValTyp:    p.ValTyp,
+		KeyTyp:    p.KeyTyp,
+		ValRestrs: make([]*template.TRestriction, 0),
+		KeyRestrs: make([]*template.TRestriction, 0),
+		Required:  p.Required,
+		Nullable:  p.Nullable,
+		Elem:      toBareProperty(p.Elem),
+		DateTime:  p.DateTime,
+		Precision: p.Precision,
+		Scale:     p.Scale,
+	}
+}
+
+// Returns true if p1 and p2 template Property-structs have the same data
+// type (including data types of "inner" values of nested arrays and maps
+// and precision and scale of decimal values)
+func isSameDataType(p1, p2 *template.TProperty) bool {
+	if p1 == nil || p2 == nil {
+		return p1 == p2
+	}
+	if p1.Typ != p2.Typ || p1.ValTyp != p2.ValTyp || p1.KeyTyp != p2.KeyTyp {
+		return false
+	}
+	if p1.Precision != p2.Precision || p1.Scale != p2.Scale {
+		return false
+	}
+	if p1.Elem == nil && p2.Elem == nil {
+		return true
+	}
+	return isSameDataType(p1.Elem, p2.Elem)
 }
 
 // Buffer type which stores funcs for proper mutation of any
@@ -37,16 +109,30 @@ var (
 	intTool = mutationTool{
 		check: func(v string) bool { return intRe(v) },
 		mutate: func(v string) (interface{}, error) {
-			i, _ := strconv.Atoi(v)
-			// ignores error cus check-func validates that v is int
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				// check-func validates that v is int, so only overflow may occur
+				return nil, fmt.Errorf("value %q is out of \"int\" data type range", v)
+			}
 			return i, nil
 		},
 	}
 	floatTool = mutationTool{
-		check: func(v string) bool { return floatRe(v) },
+		check: func(v string) bool {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				// overflow is reported by mutate-func
+				ne, ok := err.(*strconv.NumError)
+				return ok && ne.Err == strconv.ErrRange
+			}
+			return !math.IsNaN(f) && !math.IsInf(f, 0)
+		},
 		mutate: func(v string) (interface{}, error) {
-			f, _ := strconv.ParseFloat(v, 64)
-			// ignores error cus check-func validates that v is float
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				// check-func validates that v is float, so only overflow may occur
+				return nil, fmt.Errorf("value %q is out of \"float\" data type range", v)
+			}
 			return f, nil
 		},
 	}
@@ -64,21 +150,183 @@ var (
 			return b, nil
 		},
 	}
-	datetimeTool = mutationTool{
-		check: func(v string) bool { return datetimeRe(v) },
+	int64Tool = mutationTool{
+		check: func(v string) bool { return intRe(v) },
 		mutate: func(v string) (interface{}, error) {
-			d, e := time.Parse(time.RFC3339, v)
-			if e != nil {
-				return nil, fmt.Errorf("\"datetime\" restriction doesn't match RFC3339 (YYYY-MM-DDTHH:MM:SSZ) format: %s", e)
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				// check-func validates that v is int64, so only overflow may occur
+				return nil, fmt.Errorf("value %q is out of \"int64\" data type range", v)
 			}
+			return i, nil
+		},
+	}
+	uintTool = mutationTool{
+		check: func(v string) bool { return uintRe(v) },
+		mutate: func(v string) (interface{}, error) {
+			u, err := strconv.ParseUint(strings.TrimPrefix(v, "+"), 10, 64)
+			if err != nil {
+				// check-func validates that v is uint, so only overflow may occur
+				return nil, fmt.Errorf("value %q is out of \"uint\" data type range", v)
+			}
+			return u, nil
+		},
+	}
+	decimalTool = mutationTool{
+		check: func(v string) bool {
+			_, ok := template.ParseDecimal(v)
+			return ok
+		},
+		mutate: func(v string) (interface{}, error) {
+			d, _ := template.ParseDecimal(v)
+			// ignores result cus check-func validates that v is decimal
+			return d, nil
+		},
+	}
+	durationTool = mutationTool{
+		check: func(v string) bool {
+			_, err := time.ParseDuration(v)
+			return err == nil
+		},
+		mutate: func(v string) (interface{}, error) {
+			d, _ := time.ParseDuration(v)
+			// ignores error cus check-func validates that v is duration
+			return d, nil
+		},
+	}
+	dateTool = mutationTool{
+		check: func(v string) bool {
+			_, ok := parseDate(v)
+			return ok
+		},
+		mutate: func(v string) (interface{}, error) {
+			d, _ := parseDate(v)
+			// ignores result cus check-func validates that v is date
 			return d, nil
 		},
 	}
+	uuidTool = mutationTool{
+		check: func(v string) bool {
+			_, ok := template.ParseUUID(v)
+			return ok
+		},
+		mutate: func(v string) (interface{}, error) {
+			u, _ := template.ParseUUID(v)
+			// ignores result cus check-func validates that v is uuid
+			return u, nil
+		},
+	}
+	bytesTool = mutationTool{
+		check: func(v string) bool {
+			_, err := base64.StdEncoding.DecodeString(v)
+			return err == nil
+		},
+		mutate: func(v string) (interface{}, error) {
+			b, _ := base64.StdEncoding.DecodeString(v)
+			// ignores error cus check-func validates that v is base64-encoded bytes
+			return b, nil
+		},
+	}
 )
 
+// Returns mutationTool-struct for t custom data type (see template.DataType)
+// which parses values using Parse method of data type
+func customTool(t template.TDataType) mutationTool {
+	return mutationTool{
+		check: func(v string) bool {
+			_, err := template.ParseCustomValue(t, v)
+			return err == nil
+		},
+		mutate: func(v string) (interface{}, error) {
+			return template.ParseCustomValue(t, v)
+		},
+	}
+}
+
+// Parses v date value written as YYYY-MM-DD (or in RFC3339 format with
+// zero time of day); returns parsed value as UTC midnight and true on
+// success
+func parseDate(v string) (time.Time, bool) {
+	d, err := time.Parse("2006-01-02", v)
+	if err == nil {
+		return d, true
+	}
+	d, err = time.Parse(time.RFC3339Nano, v)
+	if err != nil || d.Hour() != 0 || d.Minute() != 0 || d.Second() != 0 || d.Nanosecond() != 0 {
+		return time.Time{}, false
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC), true
+}
+
+// Returns mutationTool-struct for datetime data type which parses
+// values accordingly to f datetime format
+func datetimeTool(f *template.TDateTimeFormat) mutationTool {
+	return mutationTool{
+		check: func(v string) bool {
+			_, ok := parseDateTime(v, f)
+			return ok
+		},
+		mutate: func(v string) (interface{}, error) {
+			d, _ := parseDateTime(v, f)
+			// ignores result cus check-func validates that v is datetime
+			return d, nil
+		},
+	}
+}
+
+// Parses v datetime value using layout of f datetime format (values
+// without timezone are considered to be within location of format);
+// values in RFC3339 format (with optional fractional seconds and
+// timezone offset) are accepted regardless of f, which may be nil;
+// parsed value is normalized to location of format (if it's defined);
+// returns parsed value and true on success
+func parseDateTime(v string, f *template.TDateTimeFormat) (time.Time, bool) {
+	if f == nil {
+		d, err := time.Parse(time.RFC3339Nano, v)
+		return d, err == nil
+	}
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	d, err := time.ParseInLocation(f.Layout, v, loc)
+	if err != nil {
+		if d, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return time.Time{}, false
+		}
+	}
+	if f.Location != nil {
+		d = d.In(f.Location)
+	}
+	return d, true
+}
+
+// Returns true if l layout contains at least one element of datetime
+// (the same way as time.Parse uses them)
+func isDateTimeLayout(l string) bool {
+	// every element of this datetime differs from the reference one, which
+	// is used within layouts, so only layout without elements keeps as is
+	return time.Date(2001, time.February, 3, 16, 7, 8, 9, time.UTC).Format(l) != l
+}
+
+// Returns location which is described by tz - IANA timezone name or UTC
+// offset (+HH:MM); returns nil and error as result if tz is incorrect
+func toLocation(tz string) (*time.Location, error) {
+	if d, err := time.Parse("-07:00", tz); err == nil {
+		_, offset := d.Zone()
+		return time.FixedZone(tz, offset), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("timezone %q is neither IANA timezone name nor UTC offset (+HH:MM)", tz)
+	}
+	return loc, nil
+}
+
 // Returns mutationTool-struct accordingly to unprocessed (in
-// string form) t data type; returns empty struct if t is incorrect
-func getMutationTool(t string, rt template.TRestrictionType) mutationTool {
+// string form) t data type; datetime values are parsed accordingly
+// to f datetime format; returns empty struct if t is incorrect
+func getMutationTool(t string, rt template.TRestrictionType, f *template.TDateTimeFormat) mutationTool {
 	typs := strings.Split(t, "-")
 	typ := typs[0]
 
@@ -92,9 +340,23 @@ func getMutationTool(t string, rt template.TRestrictionType) mutationTool {
 	case "bool":
 		return boolTool
 	case "datetime":
-		return datetimeTool
+		return datetimeTool(f)
+	case "int64":
+		return int64Tool
+	case "uint":
+		return uintTool
+	case "decimal":
+		return decimalTool
+	case "duration":
+		return durationTool
+	case "date":
+		return dateTool
+	case "uuid":
+		return uuidTool
+	case "bytes":
+		return bytesTool
 	case "array":
-		return getMutationTool(typs[1], 0)
+		return getMutationTool(typs[1], 0, f)
 	case "map":
 		var searchTyp string
 		switch rt {
@@ -107,101 +369,130 @@ func getMutationTool(t string, rt template.TRestrictionType) mutationTool {
 		case template.TKeyRegExp:
 			searchTyp = typs[1]
 		}
-		return getMutationTool(searchTyp, 0)
+		return getMutationTool(searchTyp, 0, f)
+	}
+	if t, ok := template.LookupType(typ); ok {
+		return customTool(t)
 	}
 	return mutationTool{}
 }
 
-// Returns according DataType-const to unprocessed (in string
-// form) t data type; returns Null-const if t is incorrect or
-// not a "simple" (int, float, string, bool, datetime) data type
-func toSimpleDataType(t string) template.TDataType {
+// Returns typeBuffer-struct which stores full representation of
+// unprocessed (in string form) t "simple" data type (decimal data type
+// may be written with precision and scale - "decimal(10,2)" - or only
+// with precision - "decimal(10)"); registered custom data types are
+// also considered as "simple" ones; returns empty struct and error as
+// result if t is incorrect or not a "simple" data type
+func toSimpleDataType(t string) (typeBuffer, error) {
+	var typ template.TDataType
 	switch t {
 	case "int":
-		return template.TInt
+		typ = template.TInt
 	case "float":
-		return template.TFloat
+		typ = template.TFloat
 	case "string":
-		return template.TString
+		typ = template.TString
 	case "bool":
-		return template.TBool
+		typ = template.TBool
 	case "datetime":
-		return template.TDateTime
+		typ = template.TDateTime
+	case "int64":
+		typ = template.TInt64
+	case "uint":
+		typ = template.TUint
+	case "decimal":
+		typ = template.TDecimal
+	case "duration":
+		typ = template.TDuration
+	case "date":
+		typ = template.TDate
+	case "uuid":
+		typ = template.TUUID
+	case "bytes":
+		typ = template.TBytes
+	default:
+		if typ, ok := template.LookupType(t); ok {
+			return typeBuffer{
+				T:  typ,
+				Vt: typ,
+			}, nil
+		}
+		m := decimalTypeRe.FindStringSubmatch(t)
+		if m == nil {
+			return typeBuffer{}, fmt.Errorf("undefined data type %q", t)
+		}
+		prec, _ := strconv.Atoi(m[1])
+		scale, _ := strconv.Atoi(m[2]) // omitted scale is considered as 0
+		if prec <= 0 || scale > prec {
+			return typeBuffer{}, fmt.Errorf("data type %q should has precision greater than 0 and scale not greater than precision", t)
+		}
+		return typeBuffer{
+			T:     template.TDecimal,
+			Vt:    template.TDecimal,
+			Prec:  prec,
+			Scale: scale,
+		}, nil
+	}
+	return typeBuffer{
+		T:  typ,
+		Vt: typ,
+	}, nil
+}
+
+// Returns error if values of t data type can't be used as keys of map;
+// returns nil otherwise
+func checkKeyDataType(t template.TDataType) error {
+	if t == template.TDecimal || t == template.TBytes {
+		return fmt.Errorf("data type \"map\" can't has keys of %q data type", t)
 	}
-	return template.TNull
+	return nil
 }
 
 // Returns typeBuffer-struct which stores full representation of
 // unprocessed (in string form) t data type; returns empty struct
 // and error as reustl if t is incorrect
 func toDataType(t string) (typeBuffer, error) {
+	if strings.ContainsAny(t, "<>") {
+		return toNestedDataType(t)
+	}
 	typs := strings.Split(t, "-")
 	typ := typs[0]
 
 	switch typ {
-	case "int":
-		if len(typs) > 1 {
-			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
-		}
-		return typeBuffer{
-			T:  template.TInt,
-			Vt: template.TInt,
-		}, nil
-	case "float":
-		if len(typs) > 1 {
-			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
-		}
-		return typeBuffer{
-			T:  template.TFloat,
-			Vt: template.TFloat,
-		}, nil
-	case "string":
-		if len(typs) > 1 {
-			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
-		}
-		return typeBuffer{
-			T:  template.TString,
-			Vt: template.TString,
-		}, nil
-	case "bool":
-		if len(typs) > 1 {
-			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
-		}
-		return typeBuffer{
-			T:  template.TBool,
-			Vt: template.TBool,
-		}, nil
-	case "datetime":
-		if len(typs) > 1 {
-			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
-		}
-		return typeBuffer{
-			T:  template.TDateTime,
-			Vt: template.TDateTime,
-		}, nil
 	case "array":
 		if len(typs) > 2 {
 			return typeBuffer{}, fmt.Errorf("data type \"array\" can't has more than 1 subtype")
 		}
-		vty := toSimpleDataType(typs[1])
-		if vty == template.TNull {
+		if len(typs) < 2 {
+			return typeBuffer{}, fmt.Errorf("data type \"array\" should has 1 subtype for values")
+		}
+		vty, err := toSimpleDataType(typs[1])
+		if err != nil {
 			return typeBuffer{}, fmt.Errorf("data type \"array\" has wrong value data subtype %q", typs[1])
 		}
 		return typeBuffer{
-			T:  template.TArray,
-			Vt: vty,
+			T:     template.TArray,
+			Vt:    vty.T,
+			Prec:  vty.Prec,
+			Scale: vty.Scale,
 		}, nil
 	case "map":
 		if len(typs) > 3 {
 			return typeBuffer{}, fmt.Errorf("data type \"map\" can't has more than 2 subtypes - 1 for keys and 1 for values")
 		}
-		kty, vty := toSimpleDataType(typs[1]), toSimpleDataType(typs[2])
+		if len(typs) < 3 {
+			return typeBuffer{}, fmt.Errorf("data type \"map\" should has 2 subtypes - 1 for keys and 1 for values")
+		}
+		kty, kerr := toSimpleDataType(typs[1])
+		vty, verr := toSimpleDataType(typs[2])
 		eText := ""
-		if kty == template.TNull {
+		if kerr != nil {
 			eText += fmt.Sprintf("data type \"map\" has wrong key data subtype %q", typs[1])
+		} else if err := checkKeyDataType(kty.T); err != nil {
+			eText += err.Error()
 		}
-		if vty == template.TNull {
-			if kty == template.TNull {
+		if verr != nil {
+			if eText != "" {
 				eText += "; "
 			}
 			eText += fmt.Sprintf("\"map\" data type has wrong value data subtype %q", typs[2])
@@ -210,18 +501,97 @@ func toDataType(t string) (typeBuffer, error) {
 			return typeBuffer{}, fmt.Errorf(eText)
 		}
 		return typeBuffer{
-			T:  template.TMap,
-			Kt: kty,
-			Vt: vty,
+			T:     template.TMap,
+			Kt:    kty.T,
+			Vt:    vty.T,
+			Prec:  vty.Prec,
+			Scale: vty.Scale,
 		}, nil
 	}
+	if len(typs) > 1 {
+		if _, err := toSimpleDataType(typ); err == nil {
+			return typeBuffer{}, fmt.Errorf("data type %q can't has subtypes", typ)
+		}
+	}
+	return toSimpleDataType(t)
+}
+
+// Returns typeBuffer-struct which stores full representation of
+// unprocessed (in string form) t data type written in recursive
+// notation - "array<value type>" or "map<key type,value type>",
+// where value type may be any data type (including nested arrays
+// and maps) and key type may be only "simple" data type; returns
+// empty struct and error as result if t is incorrect
+func toNestedDataType(t string) (typeBuffer, error) {
+	t = strings.TrimSpace(t)
+	switch {
+	case strings.HasPrefix(t, "array<") && strings.HasSuffix(t, ">"):
+		vty, err := toDataType(strings.TrimSpace(t[len("array<") : len(t)-1]))
+		if err != nil {
+			return typeBuffer{}, err
+		}
+		res := typeBuffer{
+			T:  template.TArray,
+			Vt: vty.T,
+		}
+		if vty.T == template.TArray || vty.T == template.TMap {
+			res.Elem = &vty
+		} else {
+			res.Prec, res.Scale = vty.Prec, vty.Scale
+		}
+		return res, nil
+	case strings.HasPrefix(t, "map<") && strings.HasSuffix(t, ">"):
+		inner := t[len("map<") : len(t)-1]
+		// searches for the comma which separates key and value subtypes
+		// (commas of nested data types and decimal precision are skipped)
+		depth, sep := 0, -1
+		for i, r := range inner {
+			switch r {
+			case '<', '(':
+				depth++
+			case '>', ')':
+				depth--
+			case ',':
+				if depth == 0 && sep == -1 {
+					sep = i
+				}
+			}
+		}
+		if sep == -1 {
+			return typeBuffer{}, fmt.Errorf("data type \"map\" should has 2 subtypes - 1 for keys and 1 for values")
+		}
+		key := strings.TrimSpace(inner[:sep])
+		kty, err := toSimpleDataType(key)
+		if err != nil {
+			return typeBuffer{}, fmt.Errorf("data type \"map\" has wrong key data subtype %q", key)
+		}
+		if err := checkKeyDataType(kty.T); err != nil {
+			return typeBuffer{}, err
+		}
+		vty, err := toDataType(strings.TrimSpace(inner[sep+1:]))
+		if err != nil {
+			return typeBuffer{}, err
+		}
+		res := typeBuffer{
+			T:  template.TMap,
+			Kt: kty.T,
+			Vt: vty.T,
+		}
+		if vty.T == template.TArray || vty.T == template.TMap {
+			res.Elem = &vty
+		} else {
+			res.Prec, res.Scale = vty.Prec, vty.Scale
+		}
+		return res, nil
+	}
 	return typeBuffer{}, fmt.Errorf("undefined data type %q", t)
 }
 
 // Mutates r to actual temaplate Restriction-struct using t and rt
-// to correct mutation. If any error occurs or t and rt conflicts
-// with each other - returns nil and error as result
-func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string) (*template.TRestriction, error) {
+// to correct mutation (datetime values are parsed accordingly to f
+// datetime format). If any error occurs or t and rt conflicts with
+// each other - returns nil and error as result
+func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string, f *template.TDateTimeFormat) (*template.TRestriction, error) {
 	if t.T == template.TNull {
 		return nil, fmt.Errorf("restriction %q can't be inferred because of undefined or wrong data type of restricted property", r)
 	}
@@ -230,6 +600,10 @@ func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string) (*templat
 		RestrTyp: rt,
 	}
 
+	if (t.Vt == template.TArray || t.Vt == template.TMap) && rt != template.TKeyValue && rt != template.TKeyRegExp {
+		return nil, fmt.Errorf("restriction %q of nested %q values should be defined within \"inner\" restrictions", r, t.Vt)
+	}
+
 	if rt == template.TKeyValue || rt == template.TKeyRegExp {
 		if t.T != template.TMap {
 			return nil, fmt.Errorf("data type %q can't has key restrictions", t.T)
@@ -237,6 +611,20 @@ func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string) (*templat
 		actual.Typ = t.Kt
 	}
 
+	switch rt {
+	case template.TMin, template.TMax, template.TExclusiveMin, template.TExclusiveMax:
+		switch actual.Typ {
+		case template.TInt, template.TFloat, template.TDateTime, template.TInt64,
+			template.TUint, template.TDecimal, template.TDuration, template.TDate:
+		default:
+			if _, ok := template.LookupType(actual.Typ.String()); ok {
+				// custom data types are ordered using their Compare method
+				break
+			}
+			return nil, fmt.Errorf("data type %q can't has range restrictions", actual.Typ)
+		}
+	}
+
 	if rt == template.TRegExp || rt == template.TKeyRegExp {
 		re, err := regexp.Compile(r)
 		if err != nil {
@@ -246,7 +634,7 @@ func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string) (*templat
 		return actual, nil
 	}
 
-	mut := getMutationTool(actual.Typ.String(), actual.RestrTyp)
+	mut := getMutationTool(actual.Typ.String(), actual.RestrTyp, f)
 	if !mut.check(r) {
 		return nil, fmt.Errorf("restriction %q doesn't match %q data type", r, t.Vt)
 	}
@@ -258,6 +646,84 @@ func mutateRestr(t typeBuffer, rt template.TRestrictionType, r string) (*templat
 	return actual, nil
 }
 
+// Mutates n to actual template Restriction-struct of size restriction (length
+// of strings, amount of array items or map entries) using t and rt to check
+// restriction applicability; unique items restriction ignores n. If t and rt
+// conflicts with each other or n is negative - returns nil and error as result
+func mutateSizeRestr(t typeBuffer, rt template.TRestrictionType, n int) (*template.TRestriction, error) {
+	if t.T == template.TNull {
+		return nil, fmt.Errorf("restriction %q can't be inferred because of undefined or wrong data type of restricted property", rt)
+	}
+	actual := &template.TRestriction{
+		Typ:      t.T,
+		RestrTyp: rt,
+		Restr:    n,
+	}
+	switch rt {
+	case template.TMinLength, template.TMaxLength:
+		if t.Vt != template.TString && t.Vt != template.TBytes {
+			return nil, fmt.Errorf("data type %q can't has length restrictions", t.Vt)
+		}
+		actual.Typ = t.Vt
+	case template.TMinItems, template.TMaxItems, template.TUniqueItems:
+		if t.T != template.TArray {
+			return nil, fmt.Errorf("data type %q can't has items restrictions", t.T)
+		}
+	case template.TMinEntries, template.TMaxEntries:
+		if t.T != template.TMap {
+			return nil, fmt.Errorf("data type %q can't has entries restrictions", t.T)
+		}
+	}
+	if rt == template.TUniqueItems {
+		actual.Restr = true
+		return actual, nil
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%q restriction can't be less than 0", rt)
+	}
+	return actual, nil
+}
+
+// Mutates name to actual template Restriction-struct of validator restriction
+// using t to check restriction applicability. If validator with name isn't
+// registered or t can't has such restriction - returns nil and error as result
+func mutateValidatorRestr(t typeBuffer, name string) (*template.TRestriction, error) {
+	if t.T == template.TNull {
+		return nil, fmt.Errorf("restriction %q can't be inferred because of undefined or wrong data type of restricted property", name)
+	}
+	if t.Vt == template.TArray || t.Vt == template.TMap {
+		return nil, fmt.Errorf("restriction %q of nested %q values should be defined within \"inner\" restrictions", name, t.Vt)
+	}
+	if _, ok := template.LookupValidator(name); !ok {
+		return nil, fmt.Errorf("undefined validator %q", name)
+	}
+	return &template.TRestriction{
+		Typ:      t.Vt,
+		RestrTyp: template.TValidator,
+		Restr:    name,
+	}, nil
+}
+
+// Mutates d to actual default value of property using t to correct
+// mutation (datetime values are parsed accordingly to f datetime format);
+// returns nil and error as result if any error occurs or t is not a
+// "simple" (int, float, string, bool, datetime) data type
+func mutateDefault(t typeBuffer, d string, f *template.TDateTimeFormat) (interface{}, error) {
+	if t.T == template.TArray || t.T == template.TMap {
+		return nil, fmt.Errorf("data type %q can't has default value", t.T)
+	}
+	mut := getMutationTool(t.T.String(), template.TValue, f)
+	if !mut.check(d) {
+		return nil, fmt.Errorf("default value %q doesn't match %q data type", d, t.T)
+	}
+	res, err := mut.mutate(d)
+	if v, ok := res.(template.TCustomValue); ok {
+		// default value is applied to validated entities, so it's kept as is
+		return v.Val, err
+	}
+	return res, err
+}
+
 // Checks if the given re regexp-restriction contradicts any of the
 // rs value restrictions; returns false and error if contradiction
 // occurs
@@ -281,7 +747,7 @@ func checkRestrContradiction(re *template.TRestriction, rs []*template.TRestrict
 		case template.TBool:
 			str = strconv.FormatBool(v.(bool))
 		case template.TDateTime:
-			str = v.(time.Time).Format(time.RFC3339)
+			str = v.(time.Time).Format(time.RFC3339Nano)
 		}
 		if ok := regexp.MatchString(str); !ok {
 			return ok, fmt.Errorf("%q regexp contradicts %q value restriction", regexp.String(), str)
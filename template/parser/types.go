@@ -22,67 +22,177 @@ type nesting []string
 
 // Returns pretty formatted sequence of nesting in string format
 func (n nesting) String() string {
+	if len(n) == 0 {
+		return "template"
+	}
 	return "template | " + strings.Join(n, " | ")
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
-// template-file itself
+// template-file itself; include-field contains paths of included
+// template-files; settings-field contains settings of whole template
 type bTemplate struct {
-	BufLabels map[string]bLabel `yaml:"labels"`
-	BufNodes  map[string]bNode  `yaml:"nodes"`
-	BufEdges  map[string]bEdge  `yaml:"edges"`
+	BufInclude  []string             `yaml:"include"`
+	BufSettings *bSettings           `yaml:"settings"`
+	BufTypes    map[string]bProperty `yaml:"types"`
+	BufLabels   map[string]bLabel    `yaml:"labels"`
+	BufNodes    map[string]bNode     `yaml:"nodes"`
+	BufEdges    map[string]bEdge     `yaml:"edges"`
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
-// sets-field of template-file
+// settings-field of template-file
+type bSettings struct {
+	BufDateTime *bDateTime `yaml:"datetime"`
+	nesting
+}
+
+// Temporal buffer type for .yaml parsing purposes; represents
+// datetime-subfield of settings; layout-field contains layout of
+// datetime values of template (the same way as time.Parse uses it);
+// timezone-field contains IANA timezone name or UTC offset (+HH:MM)
+// which is used to normalize datetime values
+type bDateTime struct {
+	BufLayout   string `yaml:"layout"`
+	BufTimezone string `yaml:"timezone"`
+}
+
+// Temporal buffer type for .yaml parsing purposes; represents
+// sets-field of template-file; labels-field contains names of
+// inherited labels; unique_together-field contains lists of property
+// keys which values must be unique in combination; constraints-field
+// contains expressions which relate several properties
 type bLabel struct {
-	BufProps map[string]bProperty     `yaml:"properties"`
-	BufConns map[string][]bConnection `yaml:"connections"`
+	BufLabels         []string                 `yaml:"labels"`
+	BufProps          map[string]bProperty     `yaml:"properties"`
+	BufConns          map[string][]bConnection `yaml:"connections"`
+	BufUniqueTogether [][]string               `yaml:"unique_together"`
+	BufConstraints    []string                 `yaml:"constraints"`
 	nesting
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
-// nodes-field of template-file
+// nodes-field of template-file; unique_together-field contains lists
+// of property keys which values must be unique in combination; key-field
+// contains property keys which are used as identity of node; constraints-
+// field contains expressions which relate several properties; when-field
+// contains conditional requirements
 type bNode struct {
-	BufLabels []string                 `yaml:"labels"`
-	BufProps  map[string]bProperty     `yaml:"properties"`
-	BufConns  map[string][]bConnection `yaml:"connections"`
+	BufLabels         []string                 `yaml:"labels"`
+	BufProps          map[string]bProperty     `yaml:"properties"`
+	BufConns          map[string][]bConnection `yaml:"connections"`
+	BufUniqueTogether [][]string               `yaml:"unique_together"`
+	BufKey            []string                 `yaml:"key"`
+	BufConstraints    []string                 `yaml:"constraints"`
+	BufWhen           []bCondition             `yaml:"when"`
 	nesting
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
-// edges-field of template-file
+// edges-field of template-file; omitted direction-field is considered
+// as "directed"; key-field contains property keys which are used as
+// identity of edge; constraints-field contains expressions which relate
+// several properties; when-field contains conditional requirements;
+// acyclic-, tree-, connected- and max_depth-fields restrict structure of
+// subgraph formed by edges of this type
 type bEdge struct {
-	BufProps map[string]bProperty `yaml:"properties"`
+	BufDirection   string               `yaml:"direction"`
+	BufProps       map[string]bProperty `yaml:"properties"`
+	BufKey         []string             `yaml:"key"`
+	BufConstraints []string             `yaml:"constraints"`
+	BufWhen        []bCondition         `yaml:"when"`
+	BufAcyclic     bool                 `yaml:"acyclic"`
+	BufTree        bool                 `yaml:"tree"`
+	BufConnected   bool                 `yaml:"connected"`
+	BufMaxDepth    *int                 `yaml:"max_depth"`
 	nesting
 }
 
+// Temporal buffer type for .yaml parsing purposes; represents element of
+// when-field of nodes and edges; if-field contains values of properties
+// which meet the condition
+type bCondition struct {
+	BufIf   map[string]string `yaml:"if"`
+	BufThen bConditionThen    `yaml:"then"`
+}
+
+// Temporal buffer type for .yaml parsing purposes; represents then-field
+// of condition; required-field contains keys of properties which become
+// required and properties-field contains additional restrictions of
+// properties
+type bConditionThen struct {
+	BufRequired []string                      `yaml:"required"`
+	BufProps    map[string]bConditionProperty `yaml:"properties"`
+}
+
+// Temporal buffer type for .yaml parsing purposes; represents property
+// within then-field of condition
+type bConditionProperty struct {
+	BufRestrs bRestrictions `yaml:"restrictions"`
+}
+
 // Temporal buffer type for .yaml parsing purposes; represents
-// subfileds of properties-field within "nodes" and "edges"
+// subfileds of properties-field within "nodes" and "edges"; omitted
+// required-field is considered as true
 type bProperty struct {
-	BufType   string        `yaml:"type"`
-	BufRestrs bRestrictions `yaml:"restrictions"`
+	BufType     string        `yaml:"type"`
+	BufRequired *bool         `yaml:"required"`
+	BufNullable *bool         `yaml:"nullable"`
+	BufUnique   bool          `yaml:"unique"`
+	BufDefault  *string       `yaml:"default"`
+	BufRestrs   bRestrictions `yaml:"restrictions"`
 	nesting
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
 // restriction-subfiled of property-field within "nodes", "labels"
-// and "edges"
+// and "edges"; inner-field represents restrictions of "inner" values
+// of nested arrays and maps; validators-field contains names of
+// registered validators
 type bRestrictions struct {
-	BufValueRestr     []string `yaml:"values"`
-	BufRegexpRestr    []string `yaml:"regexps"`
-	BufKeyValueRestr  []string `yaml:"key_values"`
-	BufKeyRegexpRestr []string `yaml:"key_regexps"`
+	BufValueRestr     []string       `yaml:"values"`
+	BufRegexpRestr    []string       `yaml:"regexps"`
+	BufValidators     []string       `yaml:"validators"`
+	BufKeyValueRestr  []string       `yaml:"key_values"`
+	BufKeyRegexpRestr []string       `yaml:"key_regexps"`
+	BufMinRestr       *string        `yaml:"min"`
+	BufMaxRestr       *string        `yaml:"max"`
+	BufExclMinRestr   *string        `yaml:"exclusive_min"`
+	BufExclMaxRestr   *string        `yaml:"exclusive_max"`
+	BufMinLength      *int           `yaml:"min_length"`
+	BufMaxLength      *int           `yaml:"max_length"`
+	BufMinItems       *int           `yaml:"min_items"`
+	BufMaxItems       *int           `yaml:"max_items"`
+	BufUniqueItems    bool           `yaml:"unique_items"`
+	BufMinEntries     *int           `yaml:"min_entries"`
+	BufMaxEntries     *int           `yaml:"max_entries"`
+	BufInner          *bRestrictions `yaml:"inner"`
 	nesting
 }
 
 // Temporal buffer type for .yaml parsing purposes; represents
-// subfileds of connections-field within "nodes" and "labels"
+// subfileds of connections-field within "nodes" and "labels";
+// incoming-field represents ratio of incoming connections of
+// subject node and may be omitted; bidirectional-field requires
+// reverse edge for each connection; properties-field narrows or adds
+// properties of edge within this connection only; polymorphic-field
+// keeps ratios of label connection at label level
 type bConnection struct {
 	BufEdge  string `yaml:"edge"`
 	BufRatio struct {
 		Min int `yaml:"min"`
 		Max int `yaml:"max"`
 	} `yaml:"ratio"`
+	BufIncoming      *bRatio              `yaml:"incoming"`
+	BufBidirectional bool                 `yaml:"bidirectional"`
+	BufProps         map[string]bProperty `yaml:"properties"`
+	BufPolymorphic   bool                 `yaml:"polymorphic"`
 	nesting
 }
+
+// Temporal buffer type for .yaml parsing purposes; represents
+// incoming-subfiled of connection-field
+type bRatio struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
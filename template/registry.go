@@ -0,0 +1,231 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// DataType interface - represents custom data type which may be registered
+// by name (see RegisterType) and then used within templates the same way as
+// "simple" data types (as data type of property, "inner" values of arrays
+// and maps and keys of maps); values of custom data type are kept in
+// canonical form, which is returned by Assert and Parse
+type DataType interface {
+	// Asserts - is v validated value belongs to data type; returns asserted
+	// value in canonical form and true on success (values in canonical form
+	// should be asserted too, cus they are used as default values)
+	Assert(v interface{}) (interface{}, bool)
+	// Parses s value of template (restriction, default value or value of
+	// condition); returns parsed value in canonical form and nil on success
+	Parse(s string) (interface{}, error)
+	// Compares v1 and v2 values in canonical form and returns -1, 0 or +1
+	// if v1 is accordingly less, equal or greater than v2
+	Compare(v1, v2 interface{}) int
+	// Returns string representation of v value in canonical form, which is
+	// used for regexps matching, within errors and for serialization (thus
+	// Parse should accept it)
+	Format(v interface{}) string
+}
+
+// Template custom value type - represents value of custom data type in
+// canonical form (see DataType); Typ refers to its data type
+type TCustomValue struct {
+	Typ TDataType
+	Val interface{}
+}
+
+// The first data type which is assigned to registered custom data types
+// (data types which are less than it are built-in ones)
+const firstCustomType TDataType = 128
+
+// ValidatorFunc - represents custom validator which may be registered by
+// name (see RegisterValidator) and then referenced within validators
+// restriction of template; validator receives "simple" value (or "inner"
+// value of array or map) in canonical form (the same form as values of
+// restrictions have) and returns nil if value is valid
+type ValidatorFunc func(v interface{}) error
+
+// Registry of custom data types - names contains data types by names and
+// types contains registered data types in order of registration
+var registry = struct {
+	sync.RWMutex
+	names map[string]TDataType
+	types []registeredType
+}{names: make(map[string]TDataType)}
+
+// Buffer type that represents registered custom data type
+type registeredType struct {
+	name string
+	dt   DataType
+}
+
+// Registry of validators by names
+var validators = struct {
+	sync.RWMutex
+	funcs map[string]ValidatorFunc
+}{funcs: make(map[string]ValidatorFunc)}
+
+// regexp for validation of custom data types and validators names (names
+// can't contain hyphens and brackets, cus they are used within notation of
+// data types)
+var typeNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`).MatchString
+
+// Registers dt custom data type with name, so it may be used within
+// templates which are parsed after registration; returns nil at success
+// and error if name is incorrect, conflicts with built-in data type or is
+// already registered
+//
+// WARNING: custom data types can't be unregistered, so this func should
+// be called once per data type (e.g. within init-func of package)
+func RegisterType(name string, dt DataType) error {
+	if dt == nil {
+		return fmt.Errorf("data type %q can't be nil", name)
+	}
+	if !typeNameRe(name) {
+		return fmt.Errorf("data type name %q should contain only letters, digits and underscores and shouldn't start with digit", name)
+	}
+	for t := TNull; t < firstCustomType; t++ {
+		if t.String() == name {
+			return fmt.Errorf("data type %q conflicts with built-in data type", name)
+		}
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.names[name]; ok {
+		return fmt.Errorf("data type %q is already registered", name)
+	}
+	if len(registry.types) > int(^TDataType(0)-firstCustomType) {
+		return fmt.Errorf("data type %q can't be registered: too many registered data types", name)
+	}
+	registry.names[name] = firstCustomType + TDataType(len(registry.types))
+	registry.types = append(registry.types, registeredType{name: name, dt: dt})
+	return nil
+}
+
+// Registers fn validator with name, so it may be referenced within templates
+// which are parsed after registration; returns nil at success and error if
+// name is incorrect or is already registered
+//
+// WARNING: validators can't be unregistered, so this func should be called
+// once per validator (e.g. within init-func of package)
+func RegisterValidator(name string, fn ValidatorFunc) error {
+	if fn == nil {
+		return fmt.Errorf("validator %q can't be nil", name)
+	}
+	if !typeNameRe(name) {
+		return fmt.Errorf("validator name %q should contain only letters, digits and underscores and shouldn't start with digit", name)
+	}
+
+	validators.Lock()
+	defer validators.Unlock()
+	if _, ok := validators.funcs[name]; ok {
+		return fmt.Errorf("validator %q is already registered", name)
+	}
+	validators.funcs[name] = fn
+	return nil
+}
+
+// Returns registered validator with name and true; returns false if
+// there is no such validator
+func LookupValidator(name string) (ValidatorFunc, bool) {
+	validators.RLock()
+	defer validators.RUnlock()
+	fn, ok := validators.funcs[name]
+	return fn, ok
+}
+
+// Returns registered custom data type with name and true; returns false
+// if there is no such data type
+func LookupType(name string) (TDataType, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	t, ok := registry.names[name]
+	return t, ok
+}
+
+// Parses s value of t custom data type; returns parsed value and nil on
+// success
+func ParseCustomValue(t TDataType, s string) (TCustomValue, error) {
+	dt, ok := customType(t)
+	if !ok {
+		return TCustomValue{}, fmt.Errorf("data type %q isn't registered", t)
+	}
+	v, err := dt.Parse(s)
+	if err != nil {
+		return TCustomValue{}, err
+	}
+	return TCustomValue{Typ: t, Val: v}, nil
+}
+
+// Returns true if t is custom data type
+func isCustom(t TDataType) bool {
+	return t >= firstCustomType
+}
+
+// Returns t custom data type and true; returns false if t isn't registered
+func customType(t TDataType) (DataType, bool) {
+	if !isCustom(t) {
+		return nil, false
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	if i := int(t - firstCustomType); i < len(registry.types) {
+		return registry.types[i].dt, true
+	}
+	return nil, false
+}
+
+// Returns name of t custom data type and true; returns false if t isn't
+// registered
+func customTypeName(t TDataType) (string, bool) {
+	if !isCustom(t) {
+		return "", false
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	if i := int(t - firstCustomType); i < len(registry.types) {
+		return registry.types[i].name, true
+	}
+	return "", false
+}
+
+// Asserts - is the data type of the underlying value of v is t custom data
+// type; returns asserted value in canonical form and true on success
+func assertCustom(t TDataType, v interface{}) (TCustomValue, bool) {
+	dt, ok := customType(t)
+	if !ok {
+		return TCustomValue{}, false
+	}
+	if cv, ok := v.(TCustomValue); ok {
+		return cv, cv.Typ == t
+	}
+	val, ok := dt.Assert(v)
+	if !ok {
+		return TCustomValue{}, false
+	}
+	return TCustomValue{Typ: t, Val: val}, true
+}
+
+// Compares v1 and v2 values of the same custom data type and returns -1,
+// 0 or +1 if v1 is accordingly less, equal or greater than v2 and true;
+// returns false if values can't be compared
+func (v1 TCustomValue) compare(v2 TCustomValue) (int, bool) {
+	if v1.Typ != v2.Typ {
+		return 0, false
+	}
+	dt, ok := customType(v1.Typ)
+	if !ok {
+		return 0, false
+	}
+	return dt.Compare(v1.Val, v2.Val), true
+}
+
+// Returns string representation of v value using Format of its data type
+func (v TCustomValue) String() string {
+	if dt, ok := customType(v.Typ); ok {
+		return dt.Format(v.Val)
+	}
+	return fmt.Sprint(v.Val)
+}
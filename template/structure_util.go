@@ -0,0 +1,294 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"stg/validation"
+	"strings"
+	"time"
+)
+
+// Buffer type that represents subgraph formed by edges of the same type;
+// nodes are indexed in deterministic order and childs (parents) contain
+// indexes of subject (main) nodes of according node; edges of undirected
+// subgraph are kept only once - from node with lesser index
+type subgraph struct {
+	nodes      []validation.Node
+	index      map[string]int
+	childs     [][]int
+	parents    [][]int
+	undirected bool
+}
+
+// Creates and returns subgraph of gr graph formed by edges with eTyp type
+// name; subgraph contains ALL nodes of node types which are connected by
+// such edges within template (even if they don't have such edges within
+// graph) and nodes which have such edges
+func (t TemplateHolder) newSubgraph(gr validation.Graph, eTyp string) *subgraph {
+	types := make(map[string]bool)
+	for m, ss := range t.Conns {
+		for s, es := range ss {
+			if _, ok := es[eTyp]; ok {
+				types[m] = true
+				types[s] = true
+			}
+		}
+	}
+	res := &subgraph{
+		index:      make(map[string]int),
+		undirected: t.Edges[eTyp].Undirected,
+	}
+
+	nodes := make([]validation.Node, 0)
+	for _, n := range gr.GetNodes() {
+		if types[n.GetNodeType()] {
+			nodes = append(nodes, n)
+		}
+	}
+	triplets := make([]validation.Triplet, 0)
+	for _, tr := range gr.GetTriplets() {
+		if tr.Main() == nil || tr.Subj() == nil || tr.Edge() == nil || tr.Edge().GetEdgeType() != eTyp {
+			continue
+		}
+		triplets = append(triplets, tr)
+		nodes = append(nodes, tr.Main(), tr.Subj())
+	}
+	ids := make([]string, 0, len(nodes))
+	byID := make(map[string]validation.Node, len(nodes))
+	for _, n := range nodes {
+		id := nodeID(n)
+		if _, ok := byID[id]; !ok {
+			ids = append(ids, id)
+			byID[id] = n
+		}
+	}
+	// graph doesn't keep order of nodes, so they are sorted to keep errors stable
+	sort.Strings(ids)
+	for i, id := range ids {
+		res.index[id] = i
+		res.nodes = append(res.nodes, byID[id])
+	}
+	res.childs = make([][]int, len(ids))
+	res.parents = make([][]int, len(ids))
+
+	seen := make(map[[2]int]bool)
+	for _, tr := range triplets {
+		m, s := res.index[nodeID(tr.Main())], res.index[nodeID(tr.Subj())]
+		// undirected edge of graph is listed in both directions, so it's
+		// counted once even if its edge type is directed (such edge is
+		// already invalid and shouldn't be reported as cycle)
+		if (res.undirected || validation.IsUndirected(tr.Edge())) && s < m {
+			m, s = s, m
+		}
+		if seen[[2]int{m, s}] {
+			continue
+		}
+		seen[[2]int{m, s}] = true
+		res.childs[m] = append(res.childs[m], s)
+		res.parents[s] = append(res.parents[s], m)
+	}
+	for i := range res.childs {
+		sort.Ints(res.childs[i])
+		sort.Ints(res.parents[i])
+	}
+	return res
+}
+
+// Returns indexes of nodes which are adjacent to node with i index
+// regardless of edges direction
+func (sg *subgraph) neighbours(i int) []int {
+	return append(append(make([]int, 0, len(sg.childs[i])+len(sg.parents[i])), sg.childs[i]...), sg.parents[i]...)
+}
+
+// Returns cycle of subgraph as path of node indexes (where the first and
+// the last indexes are equal); returns nil if subgraph is acyclic; cycle of
+// directed subgraph follows direction of edges
+func (sg *subgraph) findCycle() []int {
+	const (
+		white = iota
+		gray
+		black
+	)
+	colors := make([]int, len(sg.nodes))
+	stack := make([]int, 0)
+	var visit func(i, from int) []int
+	visit = func(i, from int) []int {
+		colors[i] = gray
+		stack = append(stack, i)
+		next := sg.childs[i]
+		if sg.undirected {
+			next = sg.neighbours(i)
+		}
+		skipped := false
+		for _, j := range next {
+			if sg.undirected && j == from && !skipped {
+				// the edge which leads to i node isn't a cycle itself
+				skipped = true
+				continue
+			}
+			switch colors[j] {
+			case gray:
+				for k := len(stack) - 1; k >= 0; k-- {
+					if stack[k] == j {
+						return append(append([]int{}, stack[k:]...), j)
+					}
+				}
+			case white:
+				if res := visit(j, i); res != nil {
+					return res
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		colors[i] = black
+		return nil
+	}
+	for i := range sg.nodes {
+		if colors[i] != white {
+			continue
+		}
+		if res := visit(i, -1); res != nil {
+			return res
+		}
+	}
+	return nil
+}
+
+// Returns weakly connected components of subgraph as lists of node indexes
+// ordered by the least index of component
+func (sg *subgraph) components() [][]int {
+	res := make([][]int, 0)
+	visited := make([]bool, len(sg.nodes))
+	for i := range sg.nodes {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		comp := []int{i}
+		for k := 0; k < len(comp); k++ {
+			for _, j := range sg.neighbours(comp[k]) {
+				if !visited[j] {
+					visited[j] = true
+					comp = append(comp, j)
+				}
+			}
+		}
+		res = append(res, comp)
+	}
+	return res
+}
+
+// Returns the longest path of acyclic directed subgraph as list of node
+// indexes; returns nil if subgraph doesn't have any edge
+//
+// WARNING: dont call this func until subgraph is checked for cycles,
+// otherwise it will run endlessly
+func (sg *subgraph) longestPath() []int {
+	depth := make([]int, len(sg.nodes))
+	next := make([]int, len(sg.nodes))
+	done := make([]bool, len(sg.nodes))
+	var visit func(i int) int
+	visit = func(i int) int {
+		if done[i] {
+			return depth[i]
+		}
+		next[i] = -1
+		for _, j := range sg.childs[i] {
+			if d := visit(j) + 1; d > depth[i] {
+				depth[i], next[i] = d, j
+			}
+		}
+		done[i] = true
+		return depth[i]
+	}
+	start := -1
+	for i := range sg.nodes {
+		if visit(i) > 0 && (start == -1 || depth[i] > depth[start]) {
+			start = i
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	res := make([]int, 0, depth[start]+1)
+	for i := start; i != -1; i = next[i] {
+		res = append(res, i)
+	}
+	return res
+}
+
+// Returns index of node which violates orientation of tree and indexes of
+// its parents; tree edges may be directed either from parent to child
+// (then every node has at most one incoming edge) or from child to parent
+// (then every node has at most one outgoing edge), so orientation which
+// is violated by fewer nodes is considered as intended one; returns -1 and
+// nil if orientation of subgraph is consistent
+func (sg *subgraph) findSeveralParents() (int, []int) {
+	if sg.undirected {
+		return -1, nil
+	}
+	topDown, bottomUp := make([]int, 0), make([]int, 0)
+	for i := range sg.nodes {
+		if len(sg.parents[i]) > 1 {
+			topDown = append(topDown, i)
+		}
+		if len(sg.childs[i]) > 1 {
+			bottomUp = append(bottomUp, i)
+		}
+	}
+	switch {
+	case len(topDown) == 0 || len(bottomUp) == 0:
+		return -1, nil
+	case len(bottomUp) <= len(topDown):
+		return bottomUp[0], sg.childs[bottomUp[0]]
+	}
+	return topDown[0], sg.parents[topDown[0]]
+}
+
+// Returns is nodes with is indexes in the form which is used within errors;
+// nodes are joined with sep
+func (sg *subgraph) format(t TemplateHolder, is []int, sep string) string {
+	res := make([]string, 0, len(is))
+	for _, i := range is {
+		res = append(res, t.formatNode(sg.nodes[i]))
+	}
+	return strings.Join(res, sep)
+}
+
+// Returns string which identifies n node among nodes of graph
+func nodeID(n validation.Node) string {
+	keys := n.GetKeys()
+	sort.Strings(keys)
+	vs := make([]interface{}, 0, 2*len(keys)+1)
+	vs = append(vs, n.GetNodeType())
+	for _, k := range keys {
+		v, _ := n.GetProp(k)
+		if t, ok := v.(time.Time); ok {
+			// the same instant of time may be represented within different locations
+			v = t.UTC().Round(0)
+		}
+		vs = append(vs, k, v)
+	}
+	return fmt.Sprintf("%#v", vs)
+}
+
+// Returns n node in the form which is used within errors - type name of
+// node and its key properties (or ALL properties if node type doesn't
+// have key)
+func (t TemplateHolder) formatNode(n validation.Node) string {
+	keys := n.GetKeys()
+	if node, ok := t.Nodes[n.GetNodeType()]; ok && len(node.Key) != 0 {
+		keys = node.Key
+	}
+	keys = append([]string{}, keys...)
+	sort.Strings(keys)
+	props := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v, ok := n.GetProp(k)
+		if !ok {
+			continue
+		}
+		props = append(props, fmt.Sprintf("%s: %s", k, formatLiteral(v)))
+	}
+	return fmt.Sprintf("%s{%s}", n.GetNodeType(), strings.Join(props, ", "))
+}
@@ -3,87 +3,143 @@ package template
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"stg/validation"
 )
 
 // Main type that contains all other template-types and their
 // interconnections: Nodes and Conns uses Node-type's names as
-// keys, Edges uses Edge-type's names as keys and Labels uses
-// Labels-type's names as keys accordingly
+// keys, Edges uses Edge-type's names as keys, Types uses named
+// data type's names as keys and Labels and LConns uses Labels-type's
+// names as keys accordingly
 type TemplateHolder struct {
-	Nodes map[string]*TNode                             // [node]
-	Edges map[string]*TEdge                             // [edge]
-	Conns map[string]map[string]map[string]*TConnection // [main node][subj node][edge]
+	Types  map[string]*TProperty                          // [named type]
+	Labels map[string]*TLabel                             // [label]
+	Nodes  map[string]*TNode                              // [node]
+	Edges  map[string]*TEdge                              // [edge]
+	Conns  map[string]map[string]map[string]*TConnection  // [main node][subj node][edge]
+	LConns map[string]map[string]map[string]*TLConnection // [main label][subj label][edge]
+	// datetime format of template (nil if template doesn't define it)
+	DateTime *TDateTimeFormat
 }
 
 // for auto check of interface implementation
 var _ validation.Validator = TemplateHolder{}
+var _ validation.Defaulter = TemplateHolder{}
+var _ validation.Keyer = TemplateHolder{}
+var _ validation.Collector = TemplateHolder{}
 
 // Tries to validate underlying data of n as node and returns true and
 // nil on success
 func (t TemplateHolder) ValidateNode(n validation.Node) (bool, error) {
-	typ := n.GetNodeType()
-	node, ok := t.Nodes[typ]
-	if !ok {
-		return false, fmt.Errorf("%q-node: there is no such node type in template", typ)
-	}
+	var res error
+	t.validateNode(n, firstError(&res))
+	return res == nil, res
+}
 
-	vKeys := n.GetKeys()
-	pKeys := make([]string, 0, len(node.Props))
-	for k := range node.Props {
-		pKeys = append(pKeys, k)
-	}
-	if err := comparePropertyKeys(pKeys, vKeys); err != nil {
-		return false, fmt.Errorf("%q-node: %s", typ, err.Error())
-	}
+// Tries to validate underlying data of e as edge and returns true and
+// nil on success
+func (t TemplateHolder) ValidateEdge(e validation.Edge) (bool, error) {
+	var res error
+	t.validateEdge(e, firstError(&res))
+	return res == nil, res
+}
 
-	for _, k := range vKeys {
-		tp := node.Props[k]
-		p, _ := n.GetProp(k)
+// Validates underlying data of tr as node (main or subject) and 1 edge
+// and returns true and nil on success
+func (t TemplateHolder) ValidateDuplet(du validation.Duplet) (bool, error) {
+	var res error
+	report := firstError(&res)
+	t.validateDuplet(du, func(interface{}) reporter { return report })
+	return res == nil, res
+}
 
-		ok, err := evaluateProperty(*tp, p)
-		if !ok {
-			strErr := fmt.Sprintf("%q-node: %s", typ, err.Error())
-			return ok, fmt.Errorf(strErr)
+// Validates underlying data of tr as 2 nodes (main and subject) and 1
+// edge and returns true and nil on success
+func (t TemplateHolder) ValidateTriplet(tr validation.Triplet) (bool, error) {
+	var res error
+	report := firstError(&res)
+	t.validateTriplet(tr, func(interface{}) reporter { return report })
+	return res == nil, res
+}
+
+// Validates underlying data of gr as fully functional graph consisted of
+// nodes and edges; returns true and nil on success
+func (t TemplateHolder) ValidateGraph(gr validation.Graph) (bool, error) {
+	var res error
+	report := firstError(&res)
+	t.validateGraph(gr, func(interface{}) reporter { return report }, false)
+	return res == nil, res
+}
+
+// Validates v (node, edge, duplet, triplet or graph) the same way as
+// according Validate-method, but doesn't interrupt on the first error -
+// walks through ALL nodes, edges and connections and collects their errors
+// into *ValidationReport, where errors are grouped by invalid entities;
+// limit is maximum amount of collected errors (non-positive limit means
+// there is no limit); returns true and nil on success
+//
+// WARNING: within graph each node is validated once (as node of graph), and
+// edges are validated as edges of their main nodes, so edges which are
+// undirected within graph (see validation.Undirected) are validated as edges
+// of both of their nodes
+func (t TemplateHolder) ValidateAll(v interface{}, limit int) (bool, error) {
+	c := newCollector(limit)
+	switch val := v.(type) {
+	case validation.Triplet:
+		t.validateTriplet(val, c.group)
+	case validation.Duplet:
+		t.validateDuplet(val, c.group)
+	case validation.Node:
+		t.validateNode(val, c.group(val))
+	case validation.Edge:
+		t.validateEdge(val, c.group(val))
+	case validation.Graph:
+		t.validateGraph(val, c.group, true)
+	default:
+		if ok, err := t.ValidateUnknown(val); !ok {
+			c.group(val)(err)
 		}
 	}
-	return true, nil
+	if c.count == 0 {
+		return true, nil
+	}
+	return false, c.report
 }
 
-// Tries to validate underlying data of e as edge and returns true and
-// nil on success
-func (t TemplateHolder) ValidateEdge(e validation.Edge) (bool, error) {
-	typ := e.GetEdgeType()
-	edge, ok := t.Edges[typ]
+// Validates underlying data of n as node and passes errors to report;
+// returns false if validation was interrupted by report
+func (t TemplateHolder) validateNode(n validation.Node, report reporter) bool {
+	typ := n.GetNodeType()
+	node, ok := t.Nodes[typ]
 	if !ok {
-		return false, fmt.Errorf("%q-edge: there is no such edge type in template", typ)
+		return report(newValidationError(ErrUnknownType, typ, "%q-node: there is no such node type in template", typ))
 	}
+	return validateEntity(node.Props, node.When, node.Constraints, n.GetKeys(), n.GetProp, func(err error) bool {
+		return report(fmt.Errorf("%q-node: %w", typ, withEntityType(err, typ)))
+	})
+}
 
-	vKeys := e.GetKeys()
-	pKeys := make([]string, 0, len(edge.Props))
-	for k := range edge.Props {
-		pKeys = append(pKeys, k)
+// Validates underlying data of e as edge and passes errors to report;
+// returns false if validation was interrupted by report
+func (t TemplateHolder) validateEdge(e validation.Edge, report reporter) bool {
+	typ := e.GetEdgeType()
+	edge, ok := t.Edges[typ]
+	if !ok {
+		return report(newValidationError(ErrUnknownType, typ, "%q-edge: there is no such edge type in template", typ))
 	}
-	if err := comparePropertyKeys(pKeys, vKeys); err != nil {
-		return false, fmt.Errorf("%q-edge: %s", typ, err.Error())
+	if err := checkDirection(edge, e); err != nil {
+		return report(err)
 	}
-
-	for _, k := range vKeys {
-		tp := edge.Props[k]
-		p, _ := e.GetProp(k)
-
-		ok, err := evaluateProperty(*tp, p)
-		if !ok {
-			strErr := fmt.Sprintf("%q-edge: %s", typ, err.Error())
-			return ok, fmt.Errorf(strErr)
-		}
-	}
-	return true, nil
+	return validateEntity(edge.Props, edge.When, edge.Constraints, e.GetKeys(), e.GetProp, func(err error) bool {
+		return report(fmt.Errorf("%q-edge: %w", typ, withEntityType(err, typ)))
+	})
 }
 
-// Validates underlying data of tr as node (main or subject) and 1 edge
-// and returns true and nil on success
-func (t TemplateHolder) ValidateDuplet(du validation.Duplet) (bool, error) {
+// Validates underlying data of du as node and 1 edge and passes errors to
+// reporters which are obtained by group for invalid entities; returns false
+// if validation was interrupted
+func (t TemplateHolder) validateDuplet(du validation.Duplet, group func(interface{}) reporter) bool {
 	ok := false
 	for sk := range t.Conns[du.Node().GetNodeType()] {
 		_, ok = t.Conns[du.Node().GetNodeType()][sk][du.Edge().GetEdgeType()]
@@ -98,56 +154,76 @@ func (t TemplateHolder) ValidateDuplet(du validation.Duplet) (bool, error) {
 		}
 	}
 	if !ok {
-		return ok, fmt.Errorf("Duplet: such connection is not exist")
-	}
-	if ok, err := t.ValidateNode(du.Node()); !ok {
-		return ok, fmt.Errorf("Node of duplet: " + err.Error())
+		if !group(du)(newValidationError(ErrConnection, "", "Duplet: such connection is not exist")) {
+			return false
+		}
 	}
-	if ok, err := t.ValidateEdge(du.Edge()); !ok {
-		return ok, fmt.Errorf("Edge of duplet: " + err.Error())
+	if !t.validateNode(du.Node(), prefixed(group(du.Node()), "Node of duplet")) {
+		return false
 	}
-	return true, nil
+	return t.validateEdge(du.Edge(), prefixed(group(du.Edge()), "Edge of duplet"))
 }
 
-// Validates underlying data of tr as 2 nodes (main and subject) and 1
-// edge and returns true and nil on success
-func (t TemplateHolder) ValidateTriplet(tr validation.Triplet) (bool, error) {
-	_, ok := t.Conns[tr.Main().GetNodeType()][tr.Subj().GetNodeType()][tr.Edge().GetEdgeType()]
-	if !ok {
-		return ok, fmt.Errorf("Triplet: such connection is not exist")
+// Validates underlying data of tr as 2 nodes (main and subject) and 1 edge
+// and passes errors to reporters which are obtained by group for invalid
+// entities; returns false if validation was interrupted
+func (t TemplateHolder) validateTriplet(tr validation.Triplet, group func(interface{}) reporter) bool {
+	mTyp, sTyp, eTyp := tr.Main().GetNodeType(), tr.Subj().GetNodeType(), tr.Edge().GetEdgeType()
+	conn, ok := t.Conns[mTyp][sTyp][eTyp]
+	if !ok && t.isReverseConn(mTyp, sTyp, tr.Edge()) {
+		// triplet may describe reverse direction of declared connection
+		conn, ok = t.Conns[sTyp][mTyp][eTyp], true
 	}
-	if ok, err := t.ValidateNode(tr.Main()); !ok {
-		return ok, fmt.Errorf("Main node of triplet: " + err.Error())
+	if !ok {
+		if !group(tr)(newValidationError(ErrConnection, "", "Triplet: such connection is not exist")) {
+			return false
+		}
 	}
-	if ok, err := t.ValidateNode(tr.Subj()); !ok {
-		return ok, fmt.Errorf("Subject node of triplet: " + err.Error())
+	if !t.validateNode(tr.Main(), prefixed(group(tr.Main()), "Main node of triplet")) {
+		return false
 	}
-	if ok, err := t.ValidateEdge(tr.Edge()); !ok {
-		return ok, fmt.Errorf("Edge of triplet: " + err.Error())
+	if !t.validateNode(tr.Subj(), prefixed(group(tr.Subj()), "Subject node of triplet")) {
+		return false
 	}
-	return true, nil
+	return t.validateConnEdge(conn, tr.Edge(), prefixed(group(tr.Edge()), "Edge of triplet"))
 }
 
 // Validates underlying data of gr as fully functional graph consisted of
-// nodes and edges; returns true and nil on success
-func (t TemplateHolder) ValidateGraph(gr validation.Graph) (bool, error) {
+// nodes and edges and passes errors to reporters which are obtained by
+// group for invalid entities (nodes, edges as triplets with their nodes
+// and nil for graph itself); if all is true each node is validated once,
+// otherwise subject nodes are also validated as childs of main nodes;
+// returns false if validation was interrupted
+func (t TemplateHolder) validateGraph(gr validation.Graph, group func(interface{}) reporter, all bool) bool {
 	nodes := gr.GetNodes()
-	for _, mNode := range nodes {
-		if ok, err := t.ValidateNode(mNode); !ok {
-			return ok, fmt.Errorf("Graph: " + err.Error())
+	reports := make([]reporter, len(nodes))
+	for i, mNode := range nodes {
+		reports[i] = group(mNode)
+		report := prefixed(reports[i], "Graph")
+		if !t.validateNode(mNode, report) {
+			return false
 		}
 
 		// subject nodes and edges validation
 		connCount := make(map[string]map[string]int)
-		childs := gr.GetNodeChilds(mNode)
-		for _, child := range childs {
+		direct := gr.GetNodeChilds(mNode)
+		childs := append(direct[:len(direct):len(direct)], t.reversedUndirected(graphParents(gr, mNode), mNode)...)
+		for j, child := range childs {
 			edgeType := child.Edge().GetEdgeType()
 			sNodeType := child.Node().GetNodeType()
-			if ok, err := t.ValidateEdge(child.Edge()); !ok {
-				return ok, fmt.Errorf("Graph: " + err.Error())
+			conn, ok := t.Conns[mNode.GetNodeType()][sNodeType][edgeType]
+			if !ok && t.isReverseConn(mNode.GetNodeType(), sNodeType, child.Edge()) {
+				conn = t.Conns[sNodeType][mNode.GetNodeType()][edgeType]
 			}
-			if ok, err := t.ValidateNode(child.Node()); !ok {
-				return ok, fmt.Errorf("Graph: " + err.Error())
+			// reversed edges are validated as edges of their main nodes
+			if !all || j < len(direct) {
+				tr := validation.NewTriplet(mNode, child.Node(), child.Edge())
+				if !t.validateConnEdge(conn, child.Edge(), prefixed(group(tr), "Graph")) {
+					return false
+				}
+			}
+			if !all && !t.validateNode(child.Node(), report) {
+				return false
 			}
 			if connCount[sNodeType] == nil {
 				connCount[sNodeType] = make(map[string]int)
@@ -156,35 +232,476 @@ func (t TemplateHolder) ValidateGraph(gr validation.Graph) (bool, error) {
 		}
 
 		// connections validation
-		triplets := gr.GetTripletsByNode(mNode)
-		for _, tr := range triplets {
-			mTyp := tr.Main().GetNodeType()
-			sTyp := tr.Subj().GetNodeType()
-			eTyp := tr.Edge().GetEdgeType()
+		var parents []validation.Duplet
+		labelConns := make(map[*TLConnection]bool)
+		counted := make(map[[2]string]bool) // [subject node, edge]
+		for _, child := range childs {
+			mTyp := mNode.GetNodeType()
+			sTyp := child.Node().GetNodeType()
+			eTyp := child.Edge().GetEdgeType()
 			conn, ok := t.Conns[mTyp][sTyp][eTyp]
 			if !ok {
-				return false, fmt.Errorf(
-					"Graph: %q: has wrong connection through %q-edge to %q-node",
-					mTyp, eTyp, sTyp)
+				if t.isReverseConn(mTyp, sTyp, child.Edge()) {
+					// it's validated as connection of subject node
+					continue
+				}
+				if !report(newValidationError(ErrConnection, mTyp,
+					"%q: has wrong connection through %q-edge to %q-node",
+					mTyp, eTyp, sTyp)) {
+					return false
+				}
+				continue
+			}
+			if conn.Bidirectional {
+				if parents == nil {
+					parents = graphParents(gr, mNode)
+				}
+				if !hasDuplet(parents, child.Node(), eTyp) {
+					if !report(newValidationError(ErrConnection, mTyp,
+						"%q: has connection through bidirectional %q-edge to %q-node without reverse one",
+						mTyp, eTyp, sTyp)) {
+						return false
+					}
+				}
 			}
+			if conn.Label != nil {
+				if !labelConns[conn.Label] {
+					labelConns[conn.Label] = true
+					if !t.validateLabelConn(mTyp, childs, conn.Label, report) {
+						return false
+					}
+				}
+				continue
+			}
+			if counted[[2]string{sTyp, eTyp}] {
+				continue
+			}
+			counted[[2]string{sTyp, eTyp}] = true
 			graphConnCount := connCount[sTyp][eTyp] // may be 0 if is not presented in map
 			if conn.Min > graphConnCount {
-				return false, fmt.Errorf(
-					"Graph: %q: has %d connection through %q-edge to %q-node, which is less then %d minimum",
+				if !report(cardinalityError(mTyp, graphConnCount, "min",
+					"%q: has %d connection through %q-edge to %q-node, which is less then %d minimum",
 					mNode.GetNodeType(),
 					graphConnCount,
-					eTyp, sTyp, conn.Min)
+					eTyp, sTyp, conn.Min)) {
+					return false
+				}
 			}
 			if conn.Max < graphConnCount && conn.Max != -1 {
-				return false, fmt.Errorf(
-					"Graph: %q: has %d connection through %q-edge to %q-node, which is larger then %d maximum",
+				if !report(cardinalityError(mTyp, graphConnCount, "max",
+					"%q: has %d connection through %q-edge to %q-node, which is larger then %d maximum",
 					mNode.GetNodeType(),
 					graphConnCount,
-					eTyp, sTyp, conn.Max)
+					eTyp, sTyp, conn.Max)) {
+					return false
+				}
+			}
+		}
+
+		// polymorphic connections are validated even if there is no any child
+		// through them (minimum of connection may be unsatisfied)
+		mTyp := mNode.GetNodeType()
+		sTyps := make([]string, 0, len(t.Conns[mTyp]))
+		for sTyp := range t.Conns[mTyp] {
+			sTyps = append(sTyps, sTyp)
+		}
+		sort.Strings(sTyps)
+		for _, sTyp := range sTyps {
+			eTyps := make([]string, 0, len(t.Conns[mTyp][sTyp]))
+			for eTyp := range t.Conns[mTyp][sTyp] {
+				eTyps = append(eTyps, eTyp)
+			}
+			sort.Strings(eTyps)
+			for _, eTyp := range eTyps {
+				lc := t.Conns[mTyp][sTyp][eTyp].Label
+				if lc == nil || labelConns[lc] {
+					continue
+				}
+				labelConns[lc] = true
+				if !t.validateLabelConn(mTyp, childs, lc, report) {
+					return false
+				}
+			}
+		}
+
+		// incoming connections validation
+		if !t.validateIncoming(gr, mNode, report) {
+			return false
+		}
+	}
+
+	// unique properties validation
+	if !t.validateUnique(nodes, func(i int) reporter { return prefixed(reports[i], "Graph") }) {
+		return false
+	}
+
+	// structural constraints validation
+	return t.validateStructure(gr, prefixed(group(nil), "Graph"))
+}
+
+// Validates that values of unique properties (and unique_together lists of
+// properties) are unique among ns nodes of the same node type (or with the
+// same label, if they are defined within label); nodes which don't contain
+// all of such properties or contain nil value are skipped; errors of ns[i]
+// node are passed to report(i); returns false if validation was interrupted
+func (t TemplateHolder) validateUnique(ns []validation.Node, report func(i int) reporter) bool {
+	// scope is a group of nodes (node type or label) with list of keys
+	type scope struct {
+		kind string
+		name string
+		keys []string
+	}
+	scopes := make([]scope, 0)
+	labels := make([]string, 0, len(t.Labels))
+	for k := range t.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		label := t.Labels[k]
+		for _, ks := range uniqueKeys(label.Props, label.UniqueTogether, nil) {
+			scopes = append(scopes, scope{"label", k, ks})
+		}
+	}
+	nodes := make([]string, 0, len(t.Nodes))
+	for k := range t.Nodes {
+		nodes = append(nodes, k)
+	}
+	sort.Strings(nodes)
+	for _, k := range nodes {
+		node := t.Nodes[k]
+		inherited := t.inheritedLabels(node.Labels)
+		for _, ks := range uniqueKeys(node.Props, node.UniqueTogether, func(p *TProperty) bool {
+			// unique properties of labels are validated among nodes with label
+			return t.isLabelProperty(p, inherited)
+		}) {
+			scopes = append(scopes, scope{"node", k, ks})
+		}
+	}
+
+	for _, sc := range scopes {
+		seen := make(map[string]bool)
+		for i, n := range ns {
+			typ := n.GetNodeType()
+			switch sc.kind {
+			case "node":
+				if typ != sc.name {
+					continue
+				}
+			case "label":
+				node, ok := t.Nodes[typ]
+				if !ok || !t.inheritedLabels(node.Labels)[sc.name] {
+					continue
+				}
+			}
+			key, ok := uniqueValue(n, t.Nodes[typ].Props, sc.keys)
+			if !ok {
+				continue
+			}
+			if seen[key] {
+				err := newValidationError(ErrUnique, typ,
+					"%q: has duplicate value of unique %s properties among nodes of %q-%s",
+					typ, formatKeys(sc.keys), sc.name, sc.kind)
+				err.Property, err.Path, err.Restriction = sc.keys[0], sc.keys[0], "unique"
+				if !report(i)(err) {
+					return false
+				}
+				continue
+			}
+			seen[key] = true
+		}
+	}
+	return true
+}
+
+// Validates structure of subgraphs formed by edges of the same type which
+// has structural constraints (acyclic, tree, connected, max depth) within
+// gr graph; error describes offending cycle, node or component as nodes
+// of graph; errors are passed to report; returns false if validation was
+// interrupted
+func (t TemplateHolder) validateStructure(gr validation.Graph, report reporter) bool {
+	edges := make([]string, 0, len(t.Edges))
+	for k := range t.Edges {
+		edges = append(edges, k)
+	}
+	sort.Strings(edges)
+	for _, k := range edges {
+		edge := t.Edges[k]
+		if !edge.Acyclic && !edge.Tree && !edge.Connected && edge.MaxDepth == 0 {
+			continue
+		}
+		sg := t.newSubgraph(gr, k)
+		sep := " -> "
+		if sg.undirected {
+			sep = " - "
+		}
+		cyclic := false
+		if edge.Acyclic || edge.Tree || edge.MaxDepth != 0 {
+			if cycle := sg.findCycle(); cycle != nil {
+				cyclic = true
+				if !report(structureError(k, "acyclic", "%q-edge: has cycle %s", k, sg.format(t, cycle, sep))) {
+					return false
+				}
+			}
+		}
+		if edge.Tree {
+			if i, parents := sg.findSeveralParents(); i != -1 {
+				if !report(structureError(k, "tree",
+					"%q-edge: %s has several parents within tree: %s",
+					k, sg.format(t, []int{i}, ""), sg.format(t, parents, ", "))) {
+					return false
+				}
+			}
+		}
+		if edge.Tree || edge.Connected {
+			if comps := sg.components(); len(comps) > 1 {
+				if !report(structureError(k, "connected",
+					"%q-edge: has disconnected component of nodes: %s",
+					k, sg.format(t, comps[1], ", "))) {
+					return false
+				}
+			}
+		}
+		// depth of cyclic subgraph isn't defined
+		if edge.MaxDepth != 0 && !cyclic {
+			if path := sg.longestPath(); len(path)-1 > edge.MaxDepth {
+				if !report(structureError(k, "max_depth",
+					"%q-edge: has path %s which is longer than %d maximum depth",
+					k, sg.format(t, path, sep), edge.MaxDepth)) {
+					return false
+				}
 			}
 		}
 	}
-	return true, nil
+	return true
+}
+
+// Validates amount of connections of main node with mTyp type name through
+// lc polymorphic label connection - childs of ALL node types with subject
+// label are counted together (except of node types which override label
+// connection by their own one); errors are passed to report; returns false
+// if validation was interrupted
+func (t TemplateHolder) validateLabelConn(mTyp string, childs []validation.Duplet, lc *TLConnection, report reporter) bool {
+	count := 0
+	for _, child := range childs {
+		conn := t.Conns[mTyp][child.Node().GetNodeType()][child.Edge().GetEdgeType()]
+		if conn != nil && conn.Label == lc {
+			count += 1
+		}
+	}
+	if lc.Min > count {
+		if !report(cardinalityError(mTyp, count, "min",
+			"%q: has %d connection through %q-edge to %q-label nodes, which is less then %d minimum of %q-%q label connection",
+			mTyp, count, lc.Edge.Typ, lc.Subj.Typ, lc.Min, lc.Main.Typ, lc.Subj.Typ)) {
+			return false
+		}
+	}
+	if lc.Max < count && lc.Max != INF {
+		if !report(cardinalityError(mTyp, count, "max",
+			"%q: has %d connection through %q-edge to %q-label nodes, which is larger then %d maximum of %q-%q label connection",
+			mTyp, count, lc.Edge.Typ, lc.Subj.Typ, lc.Max, lc.Main.Typ, lc.Subj.Typ)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validates amount of incoming connections (from main nodes) of s node
+// within gr graph; incoming connections of polymorphic label connections
+// are counted across ALL main node types with main label; errors are passed
+// to report; returns false if validation was interrupted
+func (t TemplateHolder) validateIncoming(gr validation.Graph, s validation.Node, report reporter) bool {
+	sTyp := s.GetNodeType()
+	var (
+		parents    []validation.Duplet
+		inCount    map[string]map[string]int
+		labelConns = make(map[*TLConnection]bool)
+	)
+	for mTyp := range t.Conns {
+		for eTyp, conn := range t.Conns[mTyp][sTyp] {
+			if conn.Label != nil {
+				lc := conn.Label
+				if labelConns[lc] || lc.InMin == 0 && lc.InMax == INF {
+					continue
+				}
+				labelConns[lc] = true
+				if parents == nil {
+					parents = t.nodeParents(gr, s)
+				}
+				count := 0
+				for _, parent := range parents {
+					pConn := t.Conns[parent.Node().GetNodeType()][sTyp][parent.Edge().GetEdgeType()]
+					if pConn != nil && pConn.Label == lc {
+						count += 1
+					}
+				}
+				if lc.InMin > count {
+					if !report(cardinalityError(sTyp, count, "incoming min",
+						"%q: has %d incoming connection through %q-edge from %q-label nodes, which is less then %d minimum of %q-%q label connection",
+						sTyp, count, eTyp, lc.Main.Typ, lc.InMin, lc.Main.Typ, lc.Subj.Typ)) {
+						return false
+					}
+				}
+				if lc.InMax < count && lc.InMax != INF {
+					if !report(cardinalityError(sTyp, count, "incoming max",
+						"%q: has %d incoming connection through %q-edge from %q-label nodes, which is larger then %d maximum of %q-%q label connection",
+						sTyp, count, eTyp, lc.Main.Typ, lc.InMax, lc.Main.Typ, lc.Subj.Typ)) {
+						return false
+					}
+				}
+				continue
+			}
+			if conn.InMin == 0 && conn.InMax == INF {
+				continue
+			}
+			if inCount == nil {
+				// counts parents lazily - only if there is any restriction
+				if parents == nil {
+					parents = t.nodeParents(gr, s)
+				}
+				inCount = make(map[string]map[string]int)
+				for _, parent := range parents {
+					pTyp := parent.Node().GetNodeType()
+					if inCount[pTyp] == nil {
+						inCount[pTyp] = make(map[string]int)
+					}
+					inCount[pTyp][parent.Edge().GetEdgeType()] += 1
+				}
+			}
+			graphInCount := inCount[mTyp][eTyp] // may be 0 if is not presented in map
+			if conn.InMin > graphInCount {
+				if !report(cardinalityError(sTyp, graphInCount, "incoming min",
+					"%q: has %d incoming connection through %q-edge from %q-node, which is less then %d minimum",
+					sTyp, graphInCount, eTyp, mTyp, conn.InMin)) {
+					return false
+				}
+			}
+			if conn.InMax < graphInCount && conn.InMax != INF {
+				if !report(cardinalityError(sTyp, graphInCount, "incoming max",
+					"%q: has %d incoming connection through %q-edge from %q-node, which is larger then %d maximum",
+					sTyp, graphInCount, eTyp, mTyp, conn.InMax)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// Validates underlying data of e as edge of conn connection (edge's
+// properties which are narrowed or added within connection are validated
+// against both edge's and connection's definitions); conn may be nil -
+// then e is validated as edge only; errors are passed to report; returns
+// false if validation was interrupted
+func (t TemplateHolder) validateConnEdge(conn *TConnection, e validation.Edge, report reporter) bool {
+	if conn == nil || len(conn.Props) == 0 {
+		return t.validateEdge(e, report)
+	}
+	typ := e.GetEdgeType()
+	edge, ok := t.Edges[typ]
+	if !ok {
+		return report(newValidationError(ErrUnknownType, typ, "%q-edge: there is no such edge type in template", typ))
+	}
+	if err := checkDirection(edge, e); err != nil {
+		return report(err)
+	}
+
+	props := make(map[string]*TProperty, len(edge.Props)+len(conn.Props))
+	for k, v := range edge.Props {
+		props[k] = v
+	}
+	for k, v := range conn.Props {
+		if p, ok := props[k]; ok && p.Required && !v.Required {
+			// connection can't make required property optional
+			continue
+		}
+		props[k] = v
+	}
+	return validateEntity(props, edge.When, edge.Constraints, e.GetKeys(), e.GetProp, func(err error) bool {
+		return report(fmt.Errorf("%q-edge of %q-%q connection: %w", typ, conn.Main.Typ, conn.Subj.Typ, withEntityType(err, typ)))
+	}, edge.Props, conn.Props)
+}
+
+// Returns duplets (edge + subject node) of n node within gr graph; edges
+// which are undirected within template are also presented in opposite
+// direction
+func (t TemplateHolder) nodeChilds(gr validation.Graph, n validation.Node) []validation.Duplet {
+	return append(gr.GetNodeChilds(n), t.reversedUndirected(graphParents(gr, n), n)...)
+}
+
+// Returns duplets (edge + main node) of n node within gr graph; if gr
+// doesn't implement ParentGetter-interface they are derived from triplets
+// of gr
+func graphParents(gr validation.Graph, n validation.Node) []validation.Duplet {
+	if pg, ok := gr.(validation.ParentGetter); ok {
+		return pg.GetNodeParents(n)
+	}
+	res := make([]validation.Duplet, 0)
+	for _, tr := range gr.GetTriplets() {
+		if tr.Subj() != nil && tr.Edge() != nil && validation.IsEqualNode(tr.Subj(), n) {
+			res = append(res, validation.NewDuplet(tr.Main(), tr.Edge()))
+		}
+	}
+	return res
+}
+
+// Returns duplets (edge + main node) of n node within gr graph; edges
+// which are undirected within template are also presented in opposite
+// direction
+func (t TemplateHolder) nodeParents(gr validation.Graph, n validation.Node) []validation.Duplet {
+	return append(graphParents(gr, n), t.reversedUndirected(gr.GetNodeChilds(n), n)...)
+}
+
+// Returns duplets from dus which edges are undirected within template,
+// but not within graph (graph already presents them in both directions);
+// self-loops of n node are omitted cus they are already presented in
+// both directions
+func (t TemplateHolder) reversedUndirected(dus []validation.Duplet, n validation.Node) []validation.Duplet {
+	res := make([]validation.Duplet, 0)
+	for _, du := range dus {
+		edge, ok := t.Edges[du.Edge().GetEdgeType()]
+		if !ok || !edge.Undirected || validation.IsUndirected(du.Edge()) ||
+			validation.IsEqualNode(du.Node(), n) {
+			continue
+		}
+		res = append(res, du)
+	}
+	return res
+}
+
+// Returns true if connection from main node with mTyp type name to
+// subject node with sTyp type name through e edge isn't declared, but
+// it's reverse direction of declared connection (edge type of e is
+// undirected or reverse connection is bidirectional); undirected edges
+// of graph don't make directed edge types undirected (see checkDirection)
+func (t TemplateHolder) isReverseConn(mTyp, sTyp string, e validation.Edge) bool {
+	conn, ok := t.Conns[sTyp][mTyp][e.GetEdgeType()]
+	if !ok {
+		return false
+	}
+	return conn.Bidirectional || conn.Edge.Undirected
+}
+
+// Returns error if e edge is undirected within graph (see
+// validation.Undirected), but its edge type is directed within template;
+// returns nil otherwise
+func checkDirection(edge *TEdge, e validation.Edge) error {
+	if !validation.IsUndirected(e) || edge.Undirected {
+		return nil
+	}
+	typ := e.GetEdgeType()
+	return newValidationError(ErrConnection, typ, "%q-edge: is undirected, but such edge type is directed", typ)
+}
+
+// Returns true if dus contains duplet with n node and edge with eTyp
+// type name
+func hasDuplet(dus []validation.Duplet, n validation.Node, eTyp string) bool {
+	for _, du := range dus {
+		if du.Edge().GetEdgeType() == eTyp && validation.IsEqualNode(du.Node(), n) {
+			return true
+		}
+	}
+	return false
 }
 
 // Tries to validate underlying data of v as node or edge and returns true
@@ -202,7 +719,8 @@ func (t TemplateHolder) ValidateGraph(gr validation.Graph) (bool, error) {
 // them
 func (t TemplateHolder) ValidateUnknown(v interface{}) (bool, error) {
 	okNode, okEdge := false, false
-	errNode, errEdge := fmt.Errorf("there is no such node type in template"), fmt.Errorf("there is no such edge type in template")
+	var errNode, errEdge error = newValidationError(ErrUnknownType, "", "there is no such node type in template"),
+		newValidationError(ErrUnknownType, "", "there is no such edge type in template")
 	val := reflect.ValueOf(v)
 	if asNode, asEdge := searchUnknownTypeName(t, val); asNode != nil || asEdge != nil {
 		switch val.Kind() {
@@ -221,11 +739,22 @@ func (t TemplateHolder) ValidateUnknown(v interface{}) (bool, error) {
 				okEdge, errEdge = validateUnknownStructAsEdge(asEdge, val)
 			}
 		default:
-			return false, fmt.Errorf("unknown value: value can't be evaluated - it's not a struct, map or map-based type")
+			return false, fmt.Errorf("unknown value: %w", newValidationError(ErrUnknownValue, "",
+				"value can't be evaluated - it's not a struct, map or map-based type"))
+		}
+		if asNode != nil {
+			errNode = withEntityType(errNode, asNode.Typ)
+		}
+		if asEdge != nil {
+			errEdge = withEntityType(errEdge, asEdge.Typ)
 		}
 
 		if !okNode && !okEdge {
-			return false, fmt.Errorf("unknown value: value had failed both validations: as node - %s; as edge - %s", errNode.Error(), errEdge.Error())
+			// wraps error of validation which had been actually performed
+			if asNode == nil {
+				return false, fmt.Errorf("unknown value: value had failed both validations: as node - %s; as edge - %w", errNode.Error(), errEdge)
+			}
+			return false, fmt.Errorf("unknown value: value had failed both validations: as node - %w; as edge - %s", errNode, errEdge.Error())
 		} else if okNode {
 			return true, fmt.Errorf("unknown value: value had been saccessfully validated as node")
 		} else if okEdge {
@@ -234,5 +763,59 @@ func (t TemplateHolder) ValidateUnknown(v interface{}) (bool, error) {
 			return true, fmt.Errorf("unknown value: value had been both saccessfully validated: and as node, and as edge")
 		}
 	}
-	return false, fmt.Errorf("unknown value: values type name doesn't match any of the templates nodes or edges")
+	return false, fmt.Errorf("unknown value: %w", newValidationError(ErrUnknownValue, "",
+		"values type name doesn't match any of the templates nodes or edges"))
+}
+
+// Returns identity keys of nodes and edges which are defined within
+// template; such keys may be used to build graph (see validation.NewKeyedGraph)
+func (t TemplateHolder) GraphKeys() validation.Keys {
+	res := validation.Keys{
+		Nodes: make(map[string][]string),
+		Edges: make(map[string][]string),
+	}
+	for k, v := range t.Nodes {
+		if len(v.Key) != 0 {
+			res.Nodes[k] = v.Key
+		}
+	}
+	for k, v := range t.Edges {
+		if len(v.Key) != 0 {
+			res.Edges[k] = v.Key
+		}
+	}
+	return res
+}
+
+// Returns copy of n node where all absent properties, which have default
+// values within template, are filled in by those values; returns n itself
+// if there is no such node type in template or nothing to fill in
+func (t TemplateHolder) ApplyNodeDefaults(n validation.Node) validation.Node {
+	node, ok := t.Nodes[n.GetNodeType()]
+	if !ok {
+		return n
+	}
+	props, ok := applyDefaults(node.Props, n.GetKeys(), n.GetProp)
+	if !ok {
+		return n
+	}
+	return validation.NewNode(n.GetNodeType(), props)
+}
+
+// Returns copy of e edge where all absent properties, which have default
+// values within template, are filled in by those values; returns e itself
+// if there is no such edge type in template or nothing to fill in
+func (t TemplateHolder) ApplyEdgeDefaults(e validation.Edge) validation.Edge {
+	edge, ok := t.Edges[e.GetEdgeType()]
+	if !ok {
+		return e
+	}
+	props, ok := applyDefaults(edge.Props, e.GetKeys(), e.GetProp)
+	if !ok {
+		return e
+	}
+	if validation.IsUndirected(e) {
+		return validation.NewUndirectedEdge(e.GetEdgeType(), props)
+	}
+	return validation.NewEdge(e.GetEdgeType(), props)
 }
@@ -1,5 +1,7 @@
 package template
 
+import "time"
+
 const INF = -1
 
 // Type that represents data type of template property
@@ -14,6 +16,13 @@ const (
 	TDateTime
 	TArray
 	TMap
+	TInt64
+	TUint
+	TDecimal
+	TDuration
+	TDate
+	TUUID
+	TBytes
 )
 
 // Common String-method to implement Stringer-interface
@@ -33,6 +42,23 @@ func (d TDataType) String() string {
 		return "array"
 	case TDateTime:
 		return "datetime"
+	case TInt64:
+		return "int64"
+	case TUint:
+		return "uint"
+	case TDecimal:
+		return "decimal"
+	case TDuration:
+		return "duration"
+	case TDate:
+		return "date"
+	case TUUID:
+		return "uuid"
+	case TBytes:
+		return "bytes"
+	}
+	if name, ok := customTypeName(d); ok {
+		return name
 	}
 	return "null"
 }
@@ -47,6 +73,18 @@ const (
 	TRegExp
 	TKeyValue
 	TKeyRegExp
+	TMin
+	TMax
+	TExclusiveMin
+	TExclusiveMax
+	TMinLength
+	TMaxLength
+	TMinItems
+	TMaxItems
+	TUniqueItems
+	TMinEntries
+	TMaxEntries
+	TValidator
 )
 
 // Common String-method to implement Stringer-interface
@@ -60,27 +98,105 @@ func (r TRestrictionType) String() string {
 		return "key value"
 	case TKeyRegExp:
 		return "key regexp"
+	case TMin:
+		return "min"
+	case TMax:
+		return "max"
+	case TExclusiveMin:
+		return "exclusive min"
+	case TExclusiveMax:
+		return "exclusive max"
+	case TMinLength:
+		return "min length"
+	case TMaxLength:
+		return "max length"
+	case TMinItems:
+		return "min items"
+	case TMaxItems:
+		return "max items"
+	case TUniqueItems:
+		return "unique items"
+	case TMinEntries:
+		return "min entries"
+	case TMaxEntries:
+		return "max entries"
+	case TValidator:
+		return "validator"
 	}
 	return ""
 }
 
-// Template node type - contains type name and properties
+// Template node type - contains type name, names of embedded labels
+// and properties (including properties of embedded labels); each of
+// UniqueTogether lists contains keys of properties which values must be
+// unique in combination among all nodes of this type within graph; Key
+// contains keys of properties which are used as identity of node within
+// graph (nil if identity is based on ALL properties); Constraints relate
+// several properties of node (including constraints of embedded labels);
+// When contains conditional requirements of node
 type TNode struct {
-	Typ   string
-	Props map[string]*TProperty
+	Typ            string
+	Labels         []string
+	Props          map[string]*TProperty
+	UniqueTogether [][]string
+	Key            []string
+	Constraints    []*TConstraint
+	When           []*TCondition
+}
+
+// Template label type - contains type name, names of inherited labels
+// and its own properties; labels are already embedded within according
+// nodes, so they are used only to keep template definition as is (e.g.
+// for serialization purposes)
+type TLabel struct {
+	Typ            string
+	Labels         []string
+	Props          map[string]*TProperty
+	UniqueTogether [][]string
+	Constraints    []*TConstraint
 }
 
-// Template edge type - contains type name and properties
+// Template edge type - contains type name and properties; Undirected
+// edge connects its nodes symmetrically, so connection through such edge
+// is satisfied by graph edge in any direction; Key contains keys of
+// properties which are used as identity of edge within graph (nil if
+// identity is based on ALL properties); Constraints relate several
+// properties of edge; When contains conditional requirements of edge;
+// Acyclic, Tree, Connected and MaxDepth (0 if depth isn't restricted)
+// restrict structure of subgraph formed by edges of this type
 type TEdge struct {
-	Typ   string
-	Props map[string]*TProperty
+	Typ         string
+	Props       map[string]*TProperty
+	Undirected  bool
+	Key         []string
+	Constraints []*TConstraint
+	When        []*TCondition
+	Acyclic     bool
+	Tree        bool
+	Connected   bool
+	MaxDepth    int
 }
 
 // Template property type - represents key:value-pair; contains
 // key and value's data type and restrictions; if type is "simple"
-// (int, float, string, bool, datetime) this type is also counted
-// as "inner" value type; if Type is Array it also contains type of
-// "inner" values; if Type is Map it also contains type of map keys
+// (int, float, string, bool, datetime, int64, uint, decimal, duration,
+// date, uuid, bytes) this type is also counted as "inner" value type;
+// if Type is Array it also contains type of "inner" values; if Type is
+// Map it also contains type of map keys;
+// not Required property may be absent within validated entity and
+// Nullable property may hold nil value; Default value (if it's not
+// nil) is used to fill in absent property; Unique property value must be
+// unique among all nodes of according node type (or among all nodes with
+// according label) within graph; if "inner" values of Array
+// or Map are also arrays or maps, Elem describes those "inner" values
+// (with their own restrictions) the same way; if property's data type
+// refers to named data type, Named contains its name; DateTime refers to
+// datetime format of template (nil if template doesn't define it);
+// Precision and Scale restrict decimal values (including "inner" ones) -
+// maximum amount of digits and amount of digits after decimal point (0
+// precision means that decimal values aren't restricted); restrictions
+// of custom data types (see DataType) hold TCustomValue values, while
+// default value of such data type is held in canonical form as is
 type TProperty struct {
 	Key       string
 	Typ       TDataType
@@ -88,26 +204,94 @@ type TProperty struct {
 	KeyTyp    TDataType
 	ValRestrs []*TRestriction
 	KeyRestrs []*TRestriction
+	Required  bool
+	Nullable  bool
+	Default   interface{}
+	Unique    bool
+	Elem      *TProperty
+	Named     string
+	DateTime  *TDateTimeFormat
+	Precision int
+	Scale     int
 }
 
 // Template restriction type - represents restriction of property;
-// contains type of restriction and restriction itself
+// contains type of restriction and restriction itself (validator
+// restriction contains name of registered validator, see ValidatorFunc)
 type TRestriction struct {
 	Typ      TDataType
 	RestrTyp TRestrictionType
 	Restr    interface{}
 }
 
+// Template datetime format type - Layout is used to parse datetime values
+// of template and to represent validated datetime values (e.g. for regexps
+// matching); Location (if it isn't nil) normalizes validated datetime
+// values before representation and is used to parse values of template
+// which Layout doesn't contain timezone
+type TDateTimeFormat struct {
+	Layout   string
+	Location *time.Location
+}
+
+// Template condition type - represents conditional requirements of node
+// or edge; condition is met if ALL properties with If keys hold according
+// values - then entity must satisfy Props definitions (Required property
+// must be presented and presented property must satisfy restrictions,
+// which are checked in addition to restrictions of property itself)
+type TCondition struct {
+	If    map[string]interface{}
+	Props map[string]*TProperty
+}
+
 // Template connection type - represents bound between main node and
 // subject node, connected by Edge, which ALWAYS directed from main node
 // to subject; contains main node, edge, subject node and minimum and
 // maximum possible amount of connections between ONE unique main node
-// and ANY amount of unique subject nodes using edge
+// and ANY amount of unique subject nodes using edge; InMin and InMax
+// are minimum and maximum possible amount of incoming connections
+// between ONE unique subject node and ANY amount of unique main nodes
+// using edge (0 and INF accordingly if they aren't restricted);
+// Bidirectional connection requires reverse edge of the same type
+// from subject node to main node for each connection; Props contains
+// properties of edge which are narrowed or added within this connection
+// only (edge is validated against both edge's and connection's
+// properties; nil if there is no such properties); Label refers to
+// polymorphic label connection which this connection is expanded from
+// (nil if connection isn't expanded from polymorphic one) - then amount
+// of connections is counted across ALL node types with according labels
+// and Label's ratios are used instead of connection's ones
 type TConnection struct {
 	Main *TNode
 	Edge *TEdge
 	Subj *TNode
 
-	Min int
-	Max int
+	Min           int
+	Max           int
+	InMin         int
+	InMax         int
+	Bidirectional bool
+	Props         map[string]*TProperty
+	Label         *TLConnection
+}
+
+// Template label connection type - represents bound between main node
+// with specified label and subject node with specified label, connected
+// by Edge; label connections are already embedded within according node
+// connections, so they are used only to keep template definition as is
+// (e.g. for serialization purposes); amount of Polymorphic connections is
+// counted across ALL node types with subject label (and main label for
+// incoming connections) instead of each node type separately
+type TLConnection struct {
+	Main *TLabel
+	Edge *TEdge
+	Subj *TLabel
+
+	Min           int
+	Max           int
+	InMin         int
+	InMax         int
+	Bidirectional bool
+	Props         map[string]*TProperty
+	Polymorphic   bool
 }
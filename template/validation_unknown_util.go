@@ -60,6 +60,14 @@ func validateUnknownMapAsNode(t *TNode, v reflect.Value) (bool, error) {
 		return false, err
 	}
 
+	get := canonicalGetter(t.Props, unknownPropGetter(keys, v))
+	if !evaluateConditions(t.When, get, firstError(&err)) {
+		return false, err
+	}
+	if err := evaluateConstraints(t.Constraints, get); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -75,6 +83,14 @@ func validateUnknownMapAsEdge(t *TEdge, v reflect.Value) (bool, error) {
 		return false, err
 	}
 
+	get := canonicalGetter(t.Props, unknownPropGetter(keys, v))
+	if !evaluateConditions(t.When, get, firstError(&err)) {
+		return false, err
+	}
+	if err := evaluateConstraints(t.Constraints, get); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -90,6 +106,14 @@ func validateUnknownStructAsNode(t *TNode, v reflect.Value) (bool, error) {
 		return false, err
 	}
 
+	get := canonicalGetter(t.Props, unknownPropGetter(keys, v))
+	if !evaluateConditions(t.When, get, firstError(&err)) {
+		return false, err
+	}
+	if err := evaluateConstraints(t.Constraints, get); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -105,6 +129,14 @@ func validateUnknownStructAsEdge(t *TEdge, v reflect.Value) (bool, error) {
 		return false, err
 	}
 
+	get := canonicalGetter(t.Props, unknownPropGetter(keys, v))
+	if !evaluateConditions(t.When, get, firstError(&err)) {
+		return false, err
+	}
+	if err := evaluateConstraints(t.Constraints, get); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -149,7 +181,7 @@ func evaluateUnknownMapPropertyKeys(omit string, v reflect.Value, ps map[string]
 	for k := range ps {
 		tKeys = append(tKeys, k)
 	}
-	if err := comparePropertyKeys(tKeys, vKeys); err != nil {
+	if err := comparePropertyKeys(ps, vKeys); err != nil {
 		return nil, err
 	}
 	return mapKeys(tKeys, vKeys), nil
@@ -187,8 +219,8 @@ func evaluateUnknownStructPropertyKeys(omit string, v reflect.Value, ps map[stri
 	for k := range ps {
 		tKeys = append(tKeys, k)
 	}
-	if err := comparePropertyKeys(tKeys, vUpperKeys); err != nil {
-		if err := comparePropertyKeys(tKeys, vLowerKeys); err != nil {
+	if err := comparePropertyKeys(ps, vUpperKeys); err != nil {
+		if err := comparePropertyKeys(ps, vLowerKeys); err != nil {
 			return nil, err
 		}
 	}
@@ -235,3 +267,28 @@ func validateUnknownStructProperties(omit string, ks map[string]string, v reflec
 	}
 	return true, nil
 }
+
+// Returns func which gets values of v underlying data (considered as map or
+// struct) properties by template properties keys using ks "valid" keys
+func unknownPropGetter(ks map[string]string, v reflect.Value) func(string) (interface{}, bool) {
+	tKeys := make(map[string]string, len(ks))
+	for vk, tk := range ks {
+		tKeys[tk] = vk
+	}
+	return func(k string) (interface{}, bool) {
+		vk, ok := tKeys[k]
+		if !ok {
+			return nil, false
+		}
+		var p reflect.Value
+		if v.Kind() == reflect.Map {
+			p = v.MapIndex(reflect.ValueOf(vk))
+		} else {
+			p = v.FieldByName(vk)
+		}
+		if !p.IsValid() {
+			return nil, false
+		}
+		return p.Interface(), true
+	}
+}
@@ -1,42 +1,125 @@
 package template
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"math/big"
 	"reflect"
 	"regexp"
+	"sort"
+	"stg/validation"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-// Compares vKeys values properties keys with tKeys template properties keys
-// and returns nil if doesn't match any contradictions between them
-func comparePropertyKeys(tKeys, vKeys []string) error {
+// Compares vKeys values properties keys with ps template properties and
+// returns nil if doesn't match any contradictions between them; template
+// properties which are not required may be absent within vKeys; missed
+// properties are enumerated in sorted order (the first of them is used as
+// property of validation error)
+func comparePropertyKeys(ps map[string]*TProperty, vKeys []string) error {
 	tempKeys := make(map[string]string)
-	for _, k := range tKeys {
+	for k := range ps {
 		tempKeys[k] = k
 	}
 	for _, k := range vKeys {
 		if _, ok := tempKeys[k]; !ok {
-			return fmt.Errorf("validated entity has extra %q property", k)
+			err := newValidationError(ErrExtraProperty, "", "validated entity has extra %q property", k)
+			err.Property, err.Path = k, k
+			return err
 		} else {
 			delete(tempKeys, k)
 		}
 	}
-	if len(tempKeys) != 0 {
-		missedProps := ""
-		for _, k := range tempKeys {
-			missedProps += fmt.Sprintf("%q, ", k)
+	missedProps := make([]string, 0)
+	for _, k := range tempKeys {
+		if !ps[k].Required {
+			continue
 		}
-		missedProps = strings.TrimSuffix(missedProps, ", ")
-		return fmt.Errorf("validated entity doesn't has %s properties", missedProps)
+		missedProps = append(missedProps, k)
+	}
+	if len(missedProps) != 0 {
+		sort.Strings(missedProps)
+		err := newValidationError(ErrMissingProperty, "", "validated entity doesn't has %s properties", formatKeys(missedProps))
+		err.Property, err.Path = missedProps[0], missedProps[0]
+		return err
 	}
 	return nil
 }
 
+// Validates properties with vKeys keys (their values are obtained by get) of
+// entity which is defined by ps properties, cs conditions and constraints;
+// if layers are given each property is evaluated against ALL of its
+// definitions within layers (the first layer is used to obtain canonical
+// values) instead of ps; doesn't interrupt on error occurences and passes
+// them to report; returns false if validation was interrupted by report
+func validateEntity(ps map[string]*TProperty, cs []*TCondition, constraints []*TConstraint, vKeys []string, get func(string) (interface{}, bool), report reporter, layers ...map[string]*TProperty) bool {
+	if len(layers) == 0 {
+		layers = []map[string]*TProperty{ps}
+	}
+	if err := comparePropertyKeys(ps, vKeys); err != nil && !report(err) {
+		return false
+	}
+
+	for _, k := range vKeys {
+		p, _ := get(k)
+		for _, l := range layers {
+			// extra properties are already reported
+			tp, ok := l[k]
+			if !ok || tp == nil {
+				continue
+			}
+			if ok, err := evaluateProperty(*tp, p); !ok && !report(err) {
+				return false
+			}
+		}
+	}
+	cget := canonicalGetter(layers[0], get)
+	if !evaluateConditions(cs, cget, report) {
+		return false
+	}
+	for i := range constraints {
+		if err := evaluateConstraints(constraints[i:i+1], cget); err != nil && !report(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// Merges vKeys values properties (which are obtained by get) with default
+// values of ps template properties and returns them as new map and true;
+// returns nil and false if there is no any absent property with default value
+func applyDefaults(ps map[string]*TProperty, vKeys []string, get func(string) (interface{}, bool)) (map[string]interface{}, bool) {
+	res := make(map[string]interface{}, len(ps))
+	for _, k := range vKeys {
+		res[k], _ = get(k)
+	}
+	applied := false
+	for k, p := range ps {
+		if _, ok := res[k]; ok || p.Default == nil {
+			continue
+		}
+		res[k] = p.Default
+		applied = true
+	}
+	if !applied {
+		return nil, false
+	}
+	return res, true
+}
+
 // Parses underlying data of p as property and validates it using tp as validator
 // and returns true and nil on success
 func evaluateProperty(tp TProperty, p interface{}) (bool, error) {
+	if p == nil {
+		if tp.Nullable {
+			return true, nil
+		}
+		return false, tp.newError(ErrNullValue, nil, "%q-property: nil value isn't allowed for not nullable property", tp.Key)
+	}
 	switch tp.Typ {
 	case TInt:
 		return evaluatePropertyAsInt(tp, p)
@@ -48,12 +131,29 @@ func evaluateProperty(tp TProperty, p interface{}) (bool, error) {
 		return evaluatePropertyAsBool(tp, p)
 	case TDateTime:
 		return evaluatePropertyAsDateTime(tp, p)
+	case TInt64:
+		return evaluatePropertyAsInt64(tp, p)
+	case TUint:
+		return evaluatePropertyAsUint(tp, p)
+	case TDecimal:
+		return evaluatePropertyAsDecimal(tp, p)
+	case TDuration:
+		return evaluatePropertyAsDuration(tp, p)
+	case TDate:
+		return evaluatePropertyAsDate(tp, p)
+	case TUUID:
+		return evaluatePropertyAsUUID(tp, p)
+	case TBytes:
+		return evaluatePropertyAsBytes(tp, p)
 	case TArray:
 		return evaluatePropertyAsArr(tp, p)
 	case TMap:
 		return evaluatePropertyAsMap(tp, p)
 	}
-	return false, fmt.Errorf("%q-property: value doesn't match any possible data type", tp.Key)
+	if isCustom(tp.Typ) {
+		return evaluatePropertyAsCustom(tp, p)
+	}
+	return false, tp.newError(ErrDataType, p, "%q-property: value doesn't match any possible data type", tp.Key)
 }
 
 // Parses underlying data of p as int data type property and validates it using
@@ -61,24 +161,12 @@ func evaluateProperty(tp TProperty, p interface{}) (bool, error) {
 func evaluatePropertyAsInt(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertInt(p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: \"%d\" value doesn't match \"int\" data type", tp.Key, val)
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"int\" data type", tp.Key, p)
 	}
-	if len(tp.ValRestrs) == 0 {
-		return true, nil
-	}
-	for _, restr := range tp.ValRestrs {
-		switch restr.RestrTyp {
-		case TValue:
-			if val == restr.Restr {
-				return true, nil
-			}
-		case TRegExp:
-			if restr.Restr.(*regexp.Regexp).MatchString(strconv.FormatInt(int64(val), 10)) {
-				return true, nil
-			}
-		}
+	if err := matchRestrs(val, tp.ValRestrs, tp.DateTime); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: \"%d\" value %s", tp.Key, val, err.Error())
 	}
-	return false, fmt.Errorf("%q-property: \"%d\" value doesn't match neither restrictions", tp.Key, val)
+	return true, nil
 }
 
 // Parses underlying data of p as float data type property and validates it using
@@ -86,24 +174,12 @@ func evaluatePropertyAsInt(tp TProperty, p interface{}) (bool, error) {
 func evaluatePropertyAsFloat(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertFloat(p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: value \"%f\" doesn't match \"float\" data type", tp.Key, val)
-	}
-	if len(tp.ValRestrs) == 0 {
-		return true, nil
+		return false, tp.newError(ErrDataType, p, "%q-property: value \"%v\" doesn't match \"float\" data type", tp.Key, p)
 	}
-	for _, restr := range tp.ValRestrs {
-		switch restr.RestrTyp {
-		case TValue:
-			if val == restr.Restr {
-				return true, nil
-			}
-		case TRegExp:
-			if restr.Restr.(*regexp.Regexp).MatchString(strconv.FormatFloat(val, 'f', -1, 32)) {
-				return true, nil
-			}
-		}
+	if err := matchRestrs(val, tp.ValRestrs, tp.DateTime); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: \"%f\" value %s", tp.Key, val, err.Error())
 	}
-	return false, fmt.Errorf("%q-property: \"%f\" value doesn't match neither restrictions", tp.Key, val)
+	return true, nil
 }
 
 // Parses underlying data of p as string data type property and validates it using
@@ -111,24 +187,12 @@ func evaluatePropertyAsFloat(tp TProperty, p interface{}) (bool, error) {
 func evaluatePropertyAsString(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertString(p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: %q value doesn't match \"string\" data type", tp.Key, val)
-	}
-	if len(tp.ValRestrs) == 0 {
-		return true, nil
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"string\" data type", tp.Key, p)
 	}
-	for _, restr := range tp.ValRestrs {
-		switch restr.RestrTyp {
-		case TValue:
-			if val == restr.Restr {
-				return true, nil
-			}
-		case TRegExp:
-			if restr.Restr.(*regexp.Regexp).MatchString(val) {
-				return true, nil
-			}
-		}
+	if err := matchRestrs(val, tp.ValRestrs, tp.DateTime); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, val, err.Error())
 	}
-	return false, fmt.Errorf("%q-property: %q value doesn't match neither restrictions", tp.Key, val)
+	return true, nil
 }
 
 // Parses underlying data of p as bool data type property and validates it using
@@ -136,17 +200,12 @@ func evaluatePropertyAsString(tp TProperty, p interface{}) (bool, error) {
 func evaluatePropertyAsBool(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertBool(p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: \"%v\" value doesn't match \"bool\" data type", tp.Key, val)
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"bool\" data type", tp.Key, p)
 	}
-	if len(tp.ValRestrs) == 0 {
-		return true, nil
+	if err := matchRestrs(val, tp.ValRestrs, tp.DateTime); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: \"%v\" value %s", tp.Key, val, err.Error())
 	}
-	for _, restr := range tp.ValRestrs {
-		if restr.RestrTyp == TValue && val == restr.Restr {
-			return true, nil
-		}
-	}
-	return false, fmt.Errorf("%q-property: \"%v\" value doesn't match neither restrictions", tp.Key, val)
+	return true, nil
 }
 
 // Parses underlying data of p as datetime data type property and validates it using
@@ -154,55 +213,153 @@ func evaluatePropertyAsBool(tp TProperty, p interface{}) (bool, error) {
 func evaluatePropertyAsDateTime(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertDateTime(p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: %q value doesn't match \"datetime\" data type", tp.Key, val)
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"datetime\" data type", tp.Key, p)
 	}
-	if len(tp.ValRestrs) == 0 {
-		return true, nil
+	if err := matchRestrs(val, tp.ValRestrs, tp.DateTime); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, tp.DateTime.format(val), err.Error())
 	}
-	for _, restr := range tp.ValRestrs {
-		switch restr.RestrTyp {
-		case TValue:
-			if val == restr.Restr {
-				return true, nil
-			}
-		case TRegExp:
-			if restr.Restr.(*regexp.Regexp).MatchString(val.Format(time.RFC3339)) {
-				return true, nil
-			}
-		}
+	return true, nil
+}
+
+// Parses underlying data of p as int64 data type property (any signed integer
+// value) and validates it using tp as validator and returns true and nil on success
+func evaluatePropertyAsInt64(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertInt64(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"int64\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: \"%d\" value %s", tp.Key, val, err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as uint data type property (any unsigned integer
+// value) and validates it using tp as validator and returns true and nil on success
+func evaluatePropertyAsUint(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertUint(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"uint\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: \"%d\" value %s", tp.Key, val, err.Error())
 	}
-	return false, fmt.Errorf("%q-property: %q value doesn't match neither restrictions", tp.Key, val)
+	return true, nil
 }
 
-// Parses underlying data of p as array data type property and validates it using
+// Parses underlying data of p as decimal data type property and validates it using
+// tp as validator (including its precision and scale) and returns true and nil on
+// success
+func evaluatePropertyAsDecimal(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertDecimal(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"decimal\" data type", tp.Key, p)
+	}
+	if err := matchDecimal(val, tp.Precision, tp.Scale); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, formatValue(val), err.Error())
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, formatValue(val), err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as duration data type property and validates it using
 // tp as validator and returns true and nil on success
+func evaluatePropertyAsDuration(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertDuration(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"duration\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, val.String(), err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as date data type property (datetime value without
+// time of day) and validates it using tp as validator and returns true and nil on
+// success
+func evaluatePropertyAsDate(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertDate(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"date\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, dateFormat); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, dateFormat.format(val), err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as uuid data type property and validates it using
+// tp as validator and returns true and nil on success
+func evaluatePropertyAsUUID(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertUUID(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"uuid\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, val, err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as bytes data type property and validates it using
+// tp as validator and returns true and nil on success
+func evaluatePropertyAsBytes(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertBytes(p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"bytes\" data type", tp.Key, p)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, formatValue(val), err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as custom data type property (see DataType) and
+// validates it using tp as validator and returns true and nil on success
+func evaluatePropertyAsCustom(tp TProperty, p interface{}) (bool, error) {
+	val, ok := assertCustom(tp.Typ, p)
+	if !ok {
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match %q data type", tp.Key, p, tp.Typ)
+	}
+	if err := matchRestrs(val, tp.ValRestrs, nil); err != nil {
+		return false, tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, val.String(), err.Error())
+	}
+	return true, nil
+}
+
+// Parses underlying data of p as array data type property and validates it using
+// tp as validator and returns true and nil on success; "inner" values of nested
+// arrays and maps are validated recursively using tp.Elem
 func evaluatePropertyAsArr(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertArr(tp.ValTyp, p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: %q value doesn't match \"array\" data type", tp.Key, val)
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"array\" data type", tp.Key, p)
 	}
-	if len(tp.ValRestrs) == 0 {
+	if len(tp.ValRestrs) == 0 && tp.Elem == nil && tp.Precision == 0 {
 		return true, nil
 	}
+	if err := matchArrRestrs(val, tp.ValRestrs); err != nil {
+		return false, tp.restrictionError(p, err, "%q-property: array %s", tp.Key, err.Error())
+	}
+	f := tp.dateTimeFormat(tp.ValTyp)
 	for i := 0; i < val.Len(); i++ {
-		val := val.Index(i)
-		match := false
-		for _, restr := range tp.ValRestrs {
-			switch restr.RestrTyp {
-			case TValue:
-				if val.Interface() == restr.Restr {
-					match = true
-					break
-				}
-			case TRegExp:
-				if restr.Restr.(*regexp.Regexp).MatchString(val.String()) {
-					match = true
-					break
-				}
+		val := val.Index(i).Interface()
+		at := fmt.Sprintf("[%d]", i)
+		if tp.Elem != nil {
+			if ok, err := evaluateProperty(*tp.Elem, val); !ok {
+				return false, withPathPrefix(err, tp.Key, at)
 			}
+			continue
+		}
+		if err := tp.matchSimpleValue(tp.ValTyp, &val); err != nil {
+			return false, withPathPrefix(err, tp.Key, at)
 		}
-		if !match {
-			return false, fmt.Errorf("%q-property: %q value doesn't match neither of the restrictions", tp.Key, val.String())
+		if err := matchRestrs(val, tp.ValRestrs, f); err != nil {
+			err := tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, f.formatValue(val), err.Error())
+			return false, withPathPrefix(err, tp.Key, at)
 		}
 	}
 
@@ -210,68 +367,655 @@ func evaluatePropertyAsArr(tp TProperty, p interface{}) (bool, error) {
 }
 
 // Parses underlying data of p as map data type property and validates it using
-// tp as validator and returns true and nil on success
+// tp as validator and returns true and nil on success; "inner" values of nested
+// arrays and maps are validated recursively using tp.Elem
 func evaluatePropertyAsMap(tp TProperty, p interface{}) (bool, error) {
 	val, ok := assertMap(tp.KeyTyp, tp.ValTyp, p)
 	if !ok {
-		return false, fmt.Errorf("%q-property: %q value doesn't match \"map\" data type", tp.Key, val)
+		return false, tp.newError(ErrDataType, p, "%q-property: \"%v\" value doesn't match \"map\" data type", tp.Key, p)
 	}
-	if len(tp.ValRestrs) == 0 && len(tp.KeyRestrs) == 0 {
+	if len(tp.ValRestrs) == 0 && len(tp.KeyRestrs) == 0 && tp.Elem == nil && tp.Precision == 0 {
 		return true, nil
 	}
-	// matchCountValues, matchCountKeys := 0, 0
+	if err := matchMapRestrs(val, tp.ValRestrs); err != nil {
+		return false, tp.restrictionError(p, err, "%q-property: map %s", tp.Key, err.Error())
+	}
+	kf, vf := tp.dateTimeFormat(tp.KeyTyp), tp.dateTimeFormat(tp.ValTyp)
 	for iter := val.MapRange(); iter.Next(); {
-		val := iter.Value()
-		matchValue := false
-		for _, restr := range tp.ValRestrs {
-			switch restr.RestrTyp {
-			case TValue:
-				if val.Interface() == restr.Restr {
-					matchValue = true
-					break
-				}
-			case TRegExp:
-				if restr.Restr.(*regexp.Regexp).MatchString(val.String()) {
-					matchValue = true
-					break
+		key := iter.Key().Interface()
+		at := fmt.Sprintf("[%s]", formatValue(key))
+		if err := tp.matchSimpleValue(tp.KeyTyp, &key); err != nil {
+			return false, withPathPrefix(err, tp.Key, at)
+		}
+		if err := matchRestrs(key, tp.KeyRestrs, kf); err != nil {
+			err := tp.restrictionError(key, err, "%q-property: %q key %s", tp.Key, kf.formatValue(key), err.Error())
+			return false, withPathPrefix(err, tp.Key, at)
+		}
+		val := iter.Value().Interface()
+		if tp.Elem != nil {
+			if ok, err := evaluateProperty(*tp.Elem, val); !ok {
+				return false, withPathPrefix(err, tp.Key, at)
+			}
+			continue
+		}
+		if err := tp.matchSimpleValue(tp.ValTyp, &val); err != nil {
+			return false, withPathPrefix(err, tp.Key, at)
+		}
+		if err := matchRestrs(val, tp.ValRestrs, vf); err != nil {
+			err := tp.restrictionError(val, err, "%q-property: %q value %s", tp.Key, vf.formatValue(val), err.Error())
+			return false, withPathPrefix(err, tp.Key, at)
+		}
+	}
+	return true, nil
+}
+
+// Asserts "inner" value (of array or map) which v points to as value of
+// t "simple" data type and replaces it with its canonical form (see
+// toCanonical); decimal values are also matched with precision and scale
+// of tp; returns nil on success
+func (tp TProperty) matchSimpleValue(t TDataType, v *interface{}) error {
+	val, ok := toCanonical(t, *v)
+	if !ok {
+		return tp.newError(ErrDataType, *v, "%q-property: \"%v\" value doesn't match %q data type", tp.Key, *v, t)
+	}
+	*v = val
+	if d, ok := val.(*big.Rat); ok && t == TDecimal {
+		if err := matchDecimal(d, tp.Precision, tp.Scale); err != nil {
+			return tp.restrictionError(d, err, "%q-property: %q value %s", tp.Key, formatValue(d), err.Error())
+		}
+	}
+	return nil
+}
+
+// Returns datetime format which is used to represent values of t data
+// type of tp property - date values are represented without time of day
+// and datetime values are represented using datetime format of tp
+func (tp TProperty) dateTimeFormat(t TDataType) *TDateTimeFormat {
+	if t == TDate {
+		return dateFormat
+	}
+	return tp.DateTime
+}
+
+// Datetime format which is used to represent date values
+var dateFormat = &TDateTimeFormat{Layout: "2006-01-02"}
+
+// Returns func which gets values of properties using get and converts
+// values of "simple" data types of according ps properties to their
+// canonical form (see toCanonical), so they may be compared with values
+// of template
+func canonicalGetter(ps map[string]*TProperty, get func(string) (interface{}, bool)) func(string) (interface{}, bool) {
+	return func(k string) (interface{}, bool) {
+		v, ok := get(k)
+		p, found := ps[k]
+		if !ok || !found || v == nil {
+			return v, ok
+		}
+		if res, ok := toCanonical(p.Typ, v); ok {
+			return res, true
+		}
+		return v, true
+	}
+}
+
+// ------------- RESTRICTIONS MATCHING ---------------- //
+
+// Checks if val satisfies rs restrictions: ALL of the range restrictions (min,
+// max, exclusive min and exclusive max) and validators should be satisfied and
+// at least ONE of the value and regexp restrictions (if there is any) should be
+// satisfied;
+// datetime values are represented using f datetime format; returns nil on
+// success and error which describes unsatisfied restrictions otherwise
+func matchRestrs(val interface{}, rs []*TRestriction, f *TDateTimeFormat) error {
+	matched := false
+	// kinds of value and regexp restrictions, which are alternatives
+	alternatives := make([]string, 0, 2)
+	for _, restr := range rs {
+		switch restr.RestrTyp {
+		case TValue, TKeyValue, TRegExp, TKeyRegExp:
+			if kind := restr.RestrTyp.String(); len(alternatives) == 0 || alternatives[len(alternatives)-1] != kind {
+				alternatives = append(alternatives, kind)
+			}
+		}
+		switch restr.RestrTyp {
+		case TValue, TKeyValue:
+			if !matched && isEqualValue(val, restr.Restr) {
+				matched = true
+			}
+		case TRegExp, TKeyRegExp:
+			if !matched && restr.Restr.(*regexp.Regexp).MatchString(f.formatValue(val)) {
+				matched = true
+			}
+		case TMin, TMax, TExclusiveMin, TExclusiveMax:
+			if !matchRangeRestr(val, restr) {
+				return newRestrictionError(restr.RestrTyp.String(), "doesn't satisfy %q restriction %q", restr.RestrTyp, f.formatValue(restr.Restr))
+			}
+		case TMinLength, TMaxLength:
+			l := -1
+			switch v := val.(type) {
+			case string:
+				l = utf8.RuneCountInString(v)
+			case []byte:
+				l = len(v)
+			}
+			if l == -1 || !matchSizeRestr(l, restr) {
+				return newRestrictionError(restr.RestrTyp.String(), "doesn't satisfy %q restriction \"%d\"", restr.RestrTyp, restr.Restr)
+			}
+		case TValidator:
+			if err := matchValidator(val, restr); err != nil {
+				return err
+			}
+		}
+	}
+	if len(alternatives) != 0 && !matched {
+		return newRestrictionError(strings.Join(alternatives, "|"), "doesn't match neither restrictions")
+	}
+	return nil
+}
+
+// Creates and returns validation error of unsatisfied restriction with name
+// (it's completed by property which value doesn't satisfy restriction - see
+// TProperty.restrictionError); error message is formatted accordingly to format
+func newRestrictionError(name, format string, args ...interface{}) *ValidationError {
+	res := newValidationError(ErrRestriction, "", format, args...)
+	res.Restriction = name
+	return res
+}
+
+// Checks if val satisfies restr validator restriction (values of custom data
+// types are passed to validator in canonical form as is); returns nil on
+// success and error which describes unsatisfied validator otherwise
+func matchValidator(val interface{}, restr *TRestriction) error {
+	name, _ := restr.Restr.(string)
+	fn, ok := LookupValidator(name)
+	if !ok {
+		return newRestrictionError(TValidator.String(), "refers to undefined %q validator", name)
+	}
+	if v, ok := val.(TCustomValue); ok {
+		val = v.Val
+	}
+	if err := fn(val); err != nil {
+		return newRestrictionError(TValidator.String(), "doesn't satisfy %q validator: %s", name, err.Error())
+	}
+	return nil
+}
+
+// Checks if val array satisfies array-related restrictions among rs (min
+// items, max items and unique items); returns nil on success and error
+// which describes unsatisfied restriction otherwise
+func matchArrRestrs(val reflect.Value, rs []*TRestriction) error {
+	for _, restr := range rs {
+		switch restr.RestrTyp {
+		case TMinItems, TMaxItems:
+			if !matchSizeRestr(val.Len(), restr) {
+				return newRestrictionError(restr.RestrTyp.String(), "has %d items, which doesn't satisfy %q restriction \"%d\"", val.Len(), restr.RestrTyp, restr.Restr)
+			}
+		case TUniqueItems:
+			if i, ok := findDuplicate(val); ok {
+				return newRestrictionError(restr.RestrTyp.String(), "has duplicated %q item, which doesn't satisfy %q restriction", formatValue(val.Index(i).Interface()), restr.RestrTyp)
+			}
+		}
+	}
+	return nil
+}
+
+// Checks if val map satisfies map-related restrictions among rs (min entries
+// and max entries); returns nil on success and error which describes
+// unsatisfied restriction otherwise
+func matchMapRestrs(val reflect.Value, rs []*TRestriction) error {
+	for _, restr := range rs {
+		switch restr.RestrTyp {
+		case TMinEntries, TMaxEntries:
+			if !matchSizeRestr(val.Len(), restr) {
+				return newRestrictionError(restr.RestrTyp.String(), "has %d entries, which doesn't satisfy %q restriction \"%d\"", val.Len(), restr.RestrTyp, restr.Restr)
+			}
+		}
+	}
+	return nil
+}
+
+// Checks if l size (length of string, amount of array items or map entries)
+// satisfies restr size restriction; returns true on success
+func matchSizeRestr(l int, restr *TRestriction) bool {
+	n, ok := restr.Restr.(int)
+	if !ok {
+		return false
+	}
+	switch restr.RestrTyp {
+	case TMinLength, TMinItems, TMinEntries:
+		return l >= n
+	case TMaxLength, TMaxItems, TMaxEntries:
+		return l <= n
+	}
+	return false
+}
+
+// Checks if val decimal has at most scale digits after decimal point and at
+// most precision-scale digits before it; 0 precision means that val isn't
+// restricted; returns nil on success and error which describes unsatisfied
+// restriction otherwise
+func matchDecimal(val *big.Rat, precision, scale int) error {
+	if precision == 0 {
+		return nil
+	}
+	frac, ok := decimalScale(val)
+	whole, digits := new(big.Int).Quo(val.Num(), val.Denom()), 0
+	if whole.Sign() != 0 {
+		digits = len(whole.Abs(whole).String())
+	}
+	if !ok || frac > scale || digits > precision-scale {
+		return newValidationError(ErrDataType, "", "doesn't fit \"decimal(%d,%d)\" data type", precision, scale)
+	}
+	return nil
+}
+
+// Returns amount of digits after decimal point which is enough to
+// represent val exactly and true; returns false if val can't be
+// represented as finite decimal fraction
+func decimalScale(val *big.Rat) (int, bool) {
+	r, ten := new(big.Rat).Set(val), big.NewRat(10, 1)
+	// denominator of finite decimal fraction divides 10^n, where n doesn't
+	// exceed amount of bits of denominator
+	for n := 0; n <= val.Denom().BitLen(); n++ {
+		if r.IsInt() {
+			return n, true
+		}
+		r.Mul(r, ten)
+	}
+	return 0, false
+}
+
+// Searches for the first item of val array which duplicates any of the
+// previous items; returns its index and true if such item is found
+func findDuplicate(val reflect.Value) (int, bool) {
+	seen := make(map[interface{}]struct{}, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		v := val.Index(i).Interface()
+		// nil item has no type, but it's comparable as key
+		if v != nil && !reflect.TypeOf(v).Comparable() {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(val.Index(j).Interface(), v) {
+					return i, true
 				}
 			}
+			continue
+		}
+		if t, ok := v.(time.Time); ok {
+			// the same instant of time may be represented within different locations
+			v = t.UTC().Round(0)
+		}
+		if _, ok := seen[v]; ok {
+			return i, true
+		}
+		seen[v] = struct{}{}
+	}
+	return 0, false
+}
+
+// Evaluates cs conditions using get as source of properties values; doesn't
+// interrupt on unsatisfied requirements of met conditions and passes errors
+// explaining them to report (validation error of unsatisfied property keeps
+// its property, path, value and restriction); returns false if evaluation
+// was interrupted by report
+func evaluateConditions(cs []*TCondition, get func(string) (interface{}, bool), report reporter) bool {
+	for _, c := range cs {
+		if !isConditionMet(c, get) {
+			continue
+		}
+		keys := make([]string, 0, len(c.Props))
+		for k := range c.Props {
+			keys = append(keys, k)
 		}
-		if !matchValue {
-			return false, fmt.Errorf("%q-property: %q value doesn't match neither of the restrictions", tp.Key, val.String())
-		}
-		key := iter.Key()
-		matchKey := false
-		for _, restr := range tp.KeyRestrs {
-			switch restr.RestrTyp {
-			case TKeyValue:
-				if key.Interface() == restr.Restr {
-					matchKey = true
-					break
+		sort.Strings(keys)
+
+		missedProps := make([]string, 0)
+		for _, k := range keys {
+			tp := c.Props[k]
+			p, ok := get(k)
+			if !ok {
+				if tp.Required {
+					missedProps = append(missedProps, k)
 				}
-			case TKeyRegExp:
-				if restr.Restr.(*regexp.Regexp).MatchString(key.String()) {
-					matchKey = true
-					break
+				continue
+			}
+			if ok, err := evaluateProperty(*tp, p); !ok {
+				res := newValidationError(ErrCondition, "", "condition (%s): %s", formatCondition(c), err.Error())
+				if ve, ok := err.(*ValidationError); ok {
+					res.Property, res.Path, res.Value, res.Restriction = ve.Property, ve.Path, ve.Value, ve.Restriction
+				}
+				if !report(res) {
+					return false
 				}
 			}
 		}
-		if !matchKey {
-			return false, fmt.Errorf("%q-property: %q kay doesn't match neither of the restrictions", tp.Key, key.String())
+		if len(missedProps) != 0 {
+			res := newValidationError(ErrCondition, "", "condition (%s): validated entity doesn't has %s properties", formatCondition(c), formatKeys(missedProps))
+			res.Property, res.Path = missedProps[0], missedProps[0]
+			if !report(res) {
+				return false
+			}
 		}
 	}
+	return true
+}
 
-	// if matchCountValues < val.Len() {
-	// 	return false, fmt.Errorf("%q-property: not all map values satisfy restrictions", tp.Key)
-	// }
-	// if matchCountKeys < val.Len() {
-	// 	return false, fmt.Errorf("%q-property: not all map keys satisfy restrictions", tp.Key)
-	// }
-	return true, nil
+// Returns true if ALL properties (which are obtained by get) used within
+// c condition hold according values
+func isConditionMet(c *TCondition, get func(string) (interface{}, bool)) bool {
+	for k, v := range c.If {
+		p, ok := get(k)
+		if !ok || p == nil || !isEqualValue(p, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns c condition in the form which is used within errors
+func formatCondition(c *TCondition) string {
+	keys := make([]string, 0, len(c.If))
+	for k := range c.If {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, fmt.Sprintf("%s == %s", k, formatLiteral(c.If[k])))
+	}
+	return strings.Join(res, " && ")
+}
+
+// Evaluates cs constraints using get as source of properties values and
+// returns error explaining the first unsatisfied constraint; returns nil
+// if all of them are satisfied
+func evaluateConstraints(cs []*TConstraint, get func(string) (interface{}, bool)) error {
+	for _, c := range cs {
+		ok, err := c.evaluate(get)
+		if err != nil {
+			res := newValidationError(ErrConstraint, "", "constraint %q can't be evaluated: %s", c.Expr, err.Error())
+			res.Restriction = c.Expr
+			return res
+		}
+		if !ok {
+			res := newValidationError(ErrConstraint, "", "constraint %q isn't satisfied", c.Expr)
+			res.Restriction = c.Expr
+			return res
+		}
+	}
+	return nil
+}
+
+// Returns lists of property keys which values must be unique: each unique
+// property of ps (except of skipped ones, if skip isn't nil) as single list
+// and unique lists as is; lists are sorted to keep order of validation
+func uniqueKeys(ps map[string]*TProperty, unique [][]string, skip func(*TProperty) bool) [][]string {
+	res := make([][]string, 0, len(unique))
+	for k, p := range ps {
+		if p.Unique && (skip == nil || !skip(p)) {
+			res = append(res, []string{k})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i][0] < res[j][0]
+	})
+	return append(res, unique...)
+}
+
+// Returns string representation of values of n node properties with ks
+// keys (which are defined by ps properties) which is used to compare them;
+// values are compared in canonical form (see toCanonical); returns false if
+// any of properties is absent or holds nil value
+func uniqueValue(n validation.Node, ps map[string]*TProperty, ks []string) (string, bool) {
+	get := canonicalGetter(ps, n.GetProp)
+	vs := make([]interface{}, 0, len(ks))
+	for _, k := range ks {
+		v, ok := get(k)
+		if !ok || v == nil {
+			return "", false
+		}
+		switch val := v.(type) {
+		case time.Time:
+			// the same instant of time may be represented within different locations
+			v = val.UTC().Round(0)
+		case *big.Rat:
+			// pointers can't be compared by their representation
+			v = val.RatString()
+		case TCustomValue:
+			v = val.String()
+		}
+		vs = append(vs, v)
+	}
+	return fmt.Sprintf("%#v", vs), true
+}
+
+// Returns ks property keys in the form which is used within errors
+func formatKeys(ks []string) string {
+	res := make([]string, 0, len(ks))
+	for _, k := range ks {
+		res = append(res, strconv.Quote(k))
+	}
+	return strings.Join(res, ", ")
+}
+
+// Checks if val satisfies restr range restriction (min, max, exclusive min
+// or exclusive max); returns true on success
+func matchRangeRestr(val interface{}, restr *TRestriction) bool {
+	res, ok := compareValues(val, restr.Restr)
+	if !ok {
+		return false
+	}
+	switch restr.RestrTyp {
+	case TMin:
+		return res >= 0
+	case TMax:
+		return res <= 0
+	case TExclusiveMin:
+		return res > 0
+	case TExclusiveMax:
+		return res < 0
+	}
+	return false
+}
+
+// Compares underlying data of v1 and v2 (which both should be ints, floats,
+// datetimes, int64s, uints, durations, decimals or values of the same custom
+// data type) and returns -1, 0 or +1 if v1 is accordingly less, equal or
+// greater than v2 and true; returns false if values can't be compared
+func compareValues(v1, v2 interface{}) (int, bool) {
+	switch val1 := v1.(type) {
+	case int:
+		val2, ok := v2.(int)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1 < val2:
+			return -1, true
+		case val1 > val2:
+			return 1, true
+		}
+		return 0, true
+	case float64:
+		val2, ok := v2.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1 < val2:
+			return -1, true
+		case val1 > val2:
+			return 1, true
+		}
+		return 0, true
+	case time.Time:
+		val2, ok := v2.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1.Before(val2):
+			return -1, true
+		case val1.After(val2):
+			return 1, true
+		}
+		return 0, true
+	case int64:
+		val2, ok := v2.(int64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1 < val2:
+			return -1, true
+		case val1 > val2:
+			return 1, true
+		}
+		return 0, true
+	case uint64:
+		val2, ok := v2.(uint64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1 < val2:
+			return -1, true
+		case val1 > val2:
+			return 1, true
+		}
+		return 0, true
+	case time.Duration:
+		val2, ok := v2.(time.Duration)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case val1 < val2:
+			return -1, true
+		case val1 > val2:
+			return 1, true
+		}
+		return 0, true
+	case *big.Rat:
+		val2, ok := v2.(*big.Rat)
+		if !ok {
+			return 0, false
+		}
+		return val1.Cmp(val2), true
+	case TCustomValue:
+		val2, ok := v2.(TCustomValue)
+		if !ok {
+			return 0, false
+		}
+		return val1.compare(val2)
+	}
+	return 0, false
+}
+
+// Returns true if underlying data of v1 and v2 are equal
+func isEqualValue(v1, v2 interface{}) bool {
+	if res, ok := compareValues(v1, v2); ok {
+		return res == 0
+	}
+	if b1, ok := v1.([]byte); ok {
+		b2, ok := v2.([]byte)
+		return ok && bytes.Equal(b1, b2)
+	}
+	return v1 == v2
+}
+
+// Returns string representation of v underlying data which is used for
+// regexps matching
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case int:
+		return strconv.FormatInt(int64(val), 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 32)
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case time.Duration:
+		return val.String()
+	case *big.Rat:
+		if n, ok := decimalScale(val); ok {
+			return val.FloatString(n)
+		}
+		return val.RatString()
+	case []byte:
+		return base64.StdEncoding.EncodeToString(val)
+	case TCustomValue:
+		return val.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// Returns string representation of v underlying data which is used for
+// regexps matching - the same as formatValue does, but datetime values are
+// represented using f datetime format (if it isn't nil)
+func (f *TDateTimeFormat) formatValue(v interface{}) string {
+	if t, ok := v.(time.Time); ok && f != nil {
+		return f.format(t)
+	}
+	return formatValue(v)
+}
+
+// Returns t datetime value represented using f datetime format - t is
+// normalized to location of format (if it's defined) and formatted using
+// its layout; returns t in RFC3339 format (with fractional seconds, if t
+// has them) if f is nil
+func (f *TDateTimeFormat) format(t time.Time) string {
+	if f == nil {
+		return t.Format(time.RFC3339Nano)
+	}
+	if f.Location != nil {
+		t = t.In(f.Location)
+	}
+	return t.Format(f.Layout)
+}
+
+// Returns v underlying data in the form which is used within errors -
+// the same as formatValue does, but strings are quoted
+func formatLiteral(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return formatValue(v)
 }
 
 // -------------- BASE TYPES ASSERTATION -------------- //
 
+// regexps for validation of data types which may be represented as strings
+var (
+	decimalRe = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`).MatchString
+	uuidRe    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString
+)
+
+// Parses s value of decimal data type written in decimal notation (for
+// example "-12.50"); returns parsed value and true on success
+func ParseDecimal(s string) (*big.Rat, bool) {
+	return assertDecimal(s)
+}
+
+// Parses s value of uuid data type written in canonical form (case
+// insensitive); returns parsed value in lower case and true on success
+func ParseUUID(s string) (string, bool) {
+	return assertUUID(s)
+}
+
+// Validates v value of property using tp as validator (the same way as values
+// of validated entities are validated); returns nil on success
+func (tp TProperty) Evaluate(v interface{}) error {
+	_, err := evaluateProperty(tp, v)
+	return err
+}
+
 // Asserts - is the data type of the underlying value of v is int; returns
 // asserted int value and true on success
 func assertInt(v interface{}) (int, bool) {
@@ -313,6 +1057,124 @@ func assertDateTime(v interface{}) (time.Time, bool) {
 	return val, ok
 }
 
+// Asserts - is the data type of the underlying value of v is int64 (any signed
+// integer, except of duration); returns asserted int64 value and true on success
+func assertInt64(v interface{}) (int64, bool) {
+	if _, ok := v.(time.Duration); ok {
+		return 0, false
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	}
+	return 0, false
+}
+
+// Asserts - is the data type of the underlying value of v is uint (any unsigned
+// integer); returns asserted uint value as uint64 and true on success
+func assertUint(v interface{}) (uint64, bool) {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+// Asserts - is the data type of the underlying value of v is decimal (*big.Rat
+// or string in decimal notation); returns asserted decimal value and true on
+// success
+func assertDecimal(v interface{}) (*big.Rat, bool) {
+	switch val := v.(type) {
+	case *big.Rat:
+		return val, val != nil
+	case string:
+		if !decimalRe(val) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(val)
+	}
+	return nil, false
+}
+
+// Asserts - is the data type of the underlying value of v is duration; returns
+// asserted duration value and true on success
+func assertDuration(v interface{}) (time.Duration, bool) {
+	val, ok := v.(time.Duration)
+	return val, ok
+}
+
+// Asserts - is the data type of the underlying value of v is date (datetime
+// without time of day within its location); returns asserted date value (as
+// UTC midnight of the same date) and true on success
+func assertDate(v interface{}) (time.Time, bool) {
+	val, ok := v.(time.Time)
+	if !ok || val.Hour() != 0 || val.Minute() != 0 || val.Second() != 0 || val.Nanosecond() != 0 {
+		return time.Time{}, false
+	}
+	return time.Date(val.Year(), val.Month(), val.Day(), 0, 0, 0, 0, time.UTC), true
+}
+
+// Asserts - is the data type of the underlying value of v is uuid (string in
+// canonical form or array of 16 bytes); returns asserted uuid value as string in
+// lower case and true on success
+func assertUUID(v interface{}) (string, bool) {
+	if s, ok := v.(string); ok {
+		return strings.ToLower(s), uuidRe(s)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Array || rv.Len() != 16 || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return "", false
+	}
+	b := make([]byte, 16)
+	reflect.Copy(reflect.ValueOf(b), rv)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:]), true
+}
+
+// Asserts - is the data type of the underlying value of v is bytes (slice of
+// bytes); returns asserted bytes value and true on success
+func assertBytes(v interface{}) ([]byte, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	return rv.Bytes(), true
+}
+
+// Asserts - is the data type of the underlying value of v is a "simple" t data
+// type and returns asserted value in canonical form (int64 and uint values are
+// converted to int64 and uint64, decimal values to *big.Rat, date values to UTC
+// midnight, uuid values to string in lower case, values of custom data types to
+// TCustomValue) and true on success; values of int, float, string, bool and
+// datetime data types are returned as is
+func toCanonical(t TDataType, v interface{}) (interface{}, bool) {
+	var (
+		res interface{}
+		ok  bool
+	)
+	switch t {
+	case TInt64:
+		res, ok = assertInt64(v)
+	case TUint:
+		res, ok = assertUint(v)
+	case TDecimal:
+		res, ok = assertDecimal(v)
+	case TDuration:
+		res, ok = assertDuration(v)
+	case TDate:
+		res, ok = assertDate(v)
+	case TUUID:
+		res, ok = assertUUID(v)
+	case TBytes:
+		res, ok = assertBytes(v)
+	default:
+		if isCustom(t) {
+			return assertCustom(t, v)
+		}
+		return v, true
+	}
+	return res, ok
+}
+
 // Asserts - is the data type of the underlying value of v is array with vt
 // data type of "inner" values; returns asserted array value wrapped into
 // reflect.Value interface and true on success
@@ -321,9 +1183,15 @@ func assertArr(vt TDataType, v interface{}) (reflect.Value, bool) {
 	if i.Kind() != reflect.Slice && i.Kind() != reflect.Array {
 		return reflect.Value{}, false
 	}
-	rv := i.Index(0)
-	if !assertSimpleDataType(vt, rv) {
-		return reflect.Value{}, false
+	n := i.Len()
+	if n != 0 && i.Type().Elem().Kind() != reflect.Interface {
+		// items of the same static data type are asserted by the first one
+		n = 1
+	}
+	for j := 0; j < n; j++ {
+		if !assertSimpleDataType(vt, i.Index(j)) {
+			return reflect.Value{}, false
+		}
 	}
 	return i, true
 }
@@ -336,15 +1204,16 @@ func assertMap(kt, vt TDataType, v interface{}) (reflect.Value, bool) {
 	if i.Kind() != reflect.Map {
 		return reflect.Value{}, false
 	}
-	iter := i.MapRange()
-	iter.Next()
-	rk := iter.Key()
-	rv := iter.Value()
-	if !assertSimpleDataType(kt, rk) {
-		return reflect.Value{}, false
-	}
-	if !assertSimpleDataType(vt, rv) {
-		return reflect.Value{}, false
+	// keys and values of the same static data types are asserted by the
+	// first entry
+	all := i.Type().Key().Kind() == reflect.Interface || i.Type().Elem().Kind() == reflect.Interface
+	for iter := i.MapRange(); iter.Next(); {
+		if !assertSimpleDataType(kt, iter.Key()) || !assertSimpleDataType(vt, iter.Value()) {
+			return reflect.Value{}, false
+		}
+		if !all {
+			break
+		}
 	}
 	return i, true
 }
@@ -352,6 +1221,13 @@ func assertMap(kt, vt TDataType, v interface{}) (reflect.Value, bool) {
 // Asserts - is the data type of the underlying value of v is a "simple"
 // data type; returns true on success
 func assertSimpleDataType(t TDataType, v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			// nil "inner" value doesn't match any "simple" data type
+			return t == TArray || t == TMap
+		}
+		v = v.Elem()
+	}
 	prt := v.Kind()
 	switch t {
 	case TInt:
@@ -365,6 +1241,13 @@ func assertSimpleDataType(t TDataType, v reflect.Value) bool {
 	case TDateTime:
 		_, ok := v.Interface().(time.Time)
 		return ok
+	case TInt64, TUint, TDecimal, TDuration, TDate, TUUID, TBytes:
+		_, ok := toCanonical(t, v.Interface())
+		return ok
+	}
+	if isCustom(t) {
+		_, ok := assertCustom(t, v.Interface())
+		return ok
 	}
 	return true
 }
@@ -19,7 +19,7 @@ func (g graphHolder) insertNode(n Node) bool {
 		node:   n,
 		childs: make(map[*graphHolderNode][]Edge),
 	}
-	g[m] = struct{}{}
+	g.nodes[m] = struct{}{}
 
 	return true
 }
@@ -28,20 +28,20 @@ func (g graphHolder) insertNode(n Node) bool {
 // true at success
 func (g graphHolder) deleteNode(n Node) bool {
 	// searches for references
-	for m := range g {
+	for m := range g.nodes {
 		for s := range m.childs {
-			if !isEqualNode(s.node, n) {
+			if !g.isSameNode(s.node, n) {
 				continue
 			}
 			delete(m.childs, s)
 		}
 	}
 	// searches for node itself
-	for m := range g {
-		if !isEqualNode(m.node, n) {
+	for m := range g.nodes {
+		if !g.isSameNode(m.node, n) {
 			continue
 		}
-		delete(g, m)
+		delete(g.nodes, m)
 		m = nil
 		return true
 	}
@@ -68,6 +68,13 @@ func (g graphHolder) insertTriplet(tr Triplet) bool {
 		// it means that there is identical triplet within g exists
 		return false
 	}
+	if tr.Edge() != nil && IsUndirected(tr.Edge()) && tr.Subj() != nil {
+		if _, _, re := g.searchForMatches(NewTriplet(tr.Subj(), tr.Main(), tr.Edge())); re != nil {
+			// it means that there is identical undirected edge with opposite
+			// direction within g exists
+			return false
+		}
+	}
 
 	res := false
 
@@ -77,7 +84,7 @@ func (g graphHolder) insertTriplet(tr Triplet) bool {
 			node:   tr.Main(),
 			childs: make(map[*graphHolderNode][]Edge),
 		}
-		g[m] = struct{}{}
+		g.nodes[m] = struct{}{}
 		res = true
 	}
 
@@ -87,7 +94,7 @@ func (g graphHolder) insertTriplet(tr Triplet) bool {
 			node:   tr.Subj(),
 			childs: make(map[*graphHolderNode][]Edge),
 		}
-		g[s] = struct{}{}
+		g.nodes[s] = struct{}{}
 		res = true
 	}
 
@@ -99,14 +106,53 @@ func (g graphHolder) insertTriplet(tr Triplet) bool {
 	return res
 }
 
-// Removes edge, described by tr triplet, from the g graph; returns true at success
+// Replaces node, which is identical to n node, within the g graph by n node
+// itself keeping all its interconnections; returns true at success
+func (g graphHolder) updateNode(n Node) bool {
+	m := g.searchMainNodeMatchWide(n)
+	if m == nil {
+		return false
+	}
+	m.node = n
+	return true
+}
+
+// Replaces edge, which is identical to edge of tr triplet (between exact
+// main node and exact subject node), within the g graph by edge of tr
+// triplet itself; undirected edge may be described by tr in any direction;
+// returns true at success
+func (g graphHolder) updateEdge(tr Triplet) bool {
+	if tr.Edge() == nil {
+		return false
+	}
+	m, s, e := g.searchForMatches(tr)
+	if e == nil && IsUndirected(tr.Edge()) && tr.Subj() != nil {
+		m, s, e = g.searchForMatches(NewTriplet(tr.Subj(), tr.Main(), tr.Edge()))
+	}
+	if e == nil {
+		return false
+	}
+	for i, edge := range m.childs[s] {
+		if g.isSameEdge(edge, e) {
+			m.childs[s][i] = tr.Edge()
+			return true
+		}
+	}
+	return false
+}
+
+// Removes edge, described by tr triplet, from the g graph; undirected edge
+// may be described by tr in any direction; returns true at success
 func (g graphHolder) deleteEdge(tr Triplet) bool {
 	m, s, e := g.searchForMatches(tr)
+	if e == nil && tr.Edge() != nil && IsUndirected(tr.Edge()) && tr.Subj() != nil {
+		m, s, e = g.searchForMatches(NewTriplet(tr.Subj(), tr.Main(), tr.Edge()))
+	}
 
 	if e != nil {
 		// it means that there is identical triplet within g exists
 		for i, edge := range m.childs[s] {
-			if isEqualEdge(e, edge) {
+			if g.isSameEdge(e, edge) {
 				m.childs[s] = append(m.childs[s][:i], m.childs[s][i+1:]...)
 				return true
 			}
@@ -133,7 +179,7 @@ func (g graphHolder) findTriplets(filters ...string) []Triplet {
 	}
 
 	res := make([]Triplet, 0)
-	for m := range g {
+	for m := range g.nodes {
 		main := m.node
 		if fs[0] != "" && fs[0] != main.GetNodeType() {
 			continue
@@ -151,22 +197,30 @@ func (g graphHolder) findTriplets(filters ...string) []Triplet {
 			}
 		}
 	}
+	// undirected edges are also presented in opposite direction
+	for m := range g.nodes {
+		for s, es := range m.childs {
+			if s == m || fs[0] != "" && fs[0] != s.node.GetNodeType() ||
+				fs[1] != "" && fs[1] != m.node.GetNodeType() {
+				continue
+			}
+			for _, edge := range es {
+				if !IsUndirected(edge) || fs[2] != "" && fs[2] != edge.GetEdgeType() {
+					continue
+				}
+				res = append(res, NewTriplet(s.node, m.node, edge))
+			}
+		}
+	}
 	return res
 }
 
-// Searches for triplets where n node is main node and returns them
+// Searches for triplets where n node is main node (or any node of
+// undirected edge) and returns them
 func (g graphHolder) getGroupedTriplets(n Node) []Triplet {
 	res := make([]Triplet, 0)
-	for m := range g {
-		if !isEqualNode(m.node, n) {
-			continue
-		}
-		for s, es := range m.childs {
-			for _, e := range es {
-				res = append(res, NewTriplet(m.node, s.node, e))
-			}
-		}
-		break
+	for _, du := range g.getNodeChilds(n) {
+		res = append(res, NewTriplet(n, du.Node(), du.Edge()))
 	}
 	return res
 }
@@ -174,7 +228,7 @@ func (g graphHolder) getGroupedTriplets(n Node) []Triplet {
 // Searches for nodes described by filter node-type-name and returns them
 func (g graphHolder) findNodes(filter string) []Node {
 	res := make([]Node, 0)
-	for n := range g {
+	for n := range g.nodes {
 		main := n.node
 		if filter != "" && filter != main.GetNodeType() {
 			continue
@@ -184,12 +238,12 @@ func (g graphHolder) findNodes(filter string) []Node {
 	return res
 }
 
-// Searches for triplets where n node is main node and returns them as
-// duplets (edge + subject node)
+// Searches for triplets where n node is main node (or any node of
+// undirected edge) and returns them as duplets (edge + subject node)
 func (g graphHolder) getNodeChilds(n Node) []Duplet {
 	res := make([]Duplet, 0)
-	for m := range g {
-		if !isEqualNode(m.node, n) {
+	for m := range g.nodes {
+		if !g.isSameNode(m.node, n) {
 			continue
 		}
 		for s, es := range m.childs {
@@ -199,6 +253,51 @@ func (g graphHolder) getNodeChilds(n Node) []Duplet {
 		}
 		break
 	}
+	return append(res, g.searchUndirected(n, false)...)
+}
+
+// Searches for triplets where n node is subject node (or any node of
+// undirected edge) and returns them as duplets (edge + main node)
+func (g graphHolder) getNodeParents(n Node) []Duplet {
+	res := make([]Duplet, 0)
+	for m := range g.nodes {
+		for s, es := range m.childs {
+			if !g.isSameNode(s.node, n) {
+				continue
+			}
+			for _, e := range es {
+				res = append(res, NewDuplet(m.node, e))
+			}
+		}
+	}
+	return append(res, g.searchUndirected(n, true)...)
+}
+
+// Searches for undirected edges of n node in opposite direction and returns
+// them as duplets (edge + opposite node); if asMain is true - n node is
+// considered as main node of stored edges, otherwise - as subject node;
+// self-loops are omitted cus they are already presented in both directions
+func (g graphHolder) searchUndirected(n Node, asMain bool) []Duplet {
+	res := make([]Duplet, 0)
+	for m := range g.nodes {
+		for s, es := range m.childs {
+			if s == m {
+				continue
+			}
+			this, other := s, m
+			if asMain {
+				this, other = m, s
+			}
+			if !g.isSameNode(this.node, n) {
+				continue
+			}
+			for _, e := range es {
+				if IsUndirected(e) {
+					res = append(res, NewDuplet(other.node, e))
+				}
+			}
+		}
+	}
 	return res
 }
 
@@ -221,12 +320,12 @@ func (g graphHolder) searchForMatches(tr Triplet) (*graphHolderNode, *graphHolde
 		if tr.Subj() == nil {
 			return matchMain, nil, nil
 		}
-		matchSubj = searchSubjNodeMatchDeep(matchMain, tr.Subj())
+		matchSubj = g.searchSubjNodeMatchDeep(matchMain, tr.Subj())
 		if matchSubj != nil {
 			if tr.Edge() == nil {
 				return matchMain, matchSubj, nil
 			}
-			matchEdge = searchEdgeMatchDeep(matchMain, matchSubj, tr.Edge())
+			matchEdge = g.searchEdgeMatchDeep(matchMain, matchSubj, tr.Edge())
 			return matchMain, matchSubj, matchEdge // happy path - full match
 		}
 		matchSubj = g.searchSubjNodeMatchWide(tr.Subj())
@@ -235,8 +334,8 @@ func (g graphHolder) searchForMatches(tr Triplet) (*graphHolderNode, *graphHolde
 }
 
 func (g graphHolder) searchMainNodeMatchWide(m Node) *graphHolderNode {
-	for n := range g {
-		if isEqualNode(n.node, m) {
+	for n := range g.nodes {
+		if g.isSameNode(n.node, m) {
 			return n
 		}
 	}
@@ -246,8 +345,8 @@ func (g graphHolder) searchMainNodeMatchWide(m Node) *graphHolderNode {
 // Searches exact node withing g graph using s subject node; returns non-nil
 // graphHolderNode-pointer at succcess
 func (g graphHolder) searchSubjNodeMatchWide(s Node) *graphHolderNode {
-	for n := range g {
-		if isEqualNode(n.node, s) {
+	for n := range g.nodes {
+		if g.isSameNode(n.node, s) {
 			return n
 		}
 	}
@@ -256,9 +355,9 @@ func (g graphHolder) searchSubjNodeMatchWide(s Node) *graphHolderNode {
 
 // Searches exact node withing m graph-node's information using s subject node;
 // returns non-nil graphHolderNode-pointer at succcess
-func searchSubjNodeMatchDeep(m *graphHolderNode, s Node) *graphHolderNode {
+func (g graphHolder) searchSubjNodeMatchDeep(m *graphHolderNode, s Node) *graphHolderNode {
 	for n := range m.childs {
-		if isEqualNode(n.node, s) {
+		if g.isSameNode(n.node, s) {
 			return n
 		}
 	}
@@ -267,15 +366,67 @@ func searchSubjNodeMatchDeep(m *graphHolderNode, s Node) *graphHolderNode {
 
 // Searches exact edge withing m graph-node's information using s subject node and
 // e Edge; returns non-nil Edge-interface at succcess
-func searchEdgeMatchDeep(m, s *graphHolderNode, e Edge) Edge {
+func (g graphHolder) searchEdgeMatchDeep(m, s *graphHolderNode, e Edge) Edge {
 	for _, edge := range m.childs[s] {
-		if isEqualEdge(edge, e) {
+		if g.isSameEdge(edge, e) {
 			return e
 		}
 	}
 	return nil
 }
 
+// Returns true if n1 and n2 nodes are identical within g graph - they have
+// equal types and equal key properties (if keys are defined for their type
+// and both nodes contain them) or ALL equal properties otherwise
+func (g graphHolder) isSameNode(n1, n2 Node) bool {
+	if ks, ok := g.keys.Nodes[n1.GetNodeType()]; ok && n1.GetNodeType() == n2.GetNodeType() {
+		if res, ok := isEqualByKeys(ks, n1.GetProp, n2.GetProp); ok {
+			return res
+		}
+	}
+	return isEqualNode(n1, n2)
+}
+
+// Returns true if e1 and e2 edges are identical within g graph - they have
+// equal types and equal key properties (if keys are defined for their type
+// and both edges contain them) or ALL equal properties otherwise
+func (g graphHolder) isSameEdge(e1, e2 Edge) bool {
+	if ks, ok := g.keys.Edges[e1.GetEdgeType()]; ok && e1.GetEdgeType() == e2.GetEdgeType() {
+		if res, ok := isEqualByKeys(ks, e1.GetProp, e2.GetProp); ok {
+			return res
+		}
+	}
+	return isEqualEdge(e1, e2)
+}
+
+// Returns true (as first result) if properties with ks keys, which are
+// returned by get1 and get2 funcs, are equal; returns false as second
+// result if any of properties is absent, so keys can't be used to compare
+// entities
+func isEqualByKeys(ks []string, get1, get2 func(string) (interface{}, bool)) (bool, bool) {
+	for _, k := range ks {
+		res1, ok1 := get1(k)
+		res2, ok2 := get2(k)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		if !isEqualProp(res1, res2) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// Returns true if p1 and p2 property values are equal
+func isEqualProp(p1, p2 interface{}) bool {
+	if ok, err := tryCompareAsComplexDataType(p1, p2); ok {
+		return true
+	} else if err == nil {
+		return false
+	}
+	return p1 == p2
+}
+
 // Returns true if n1 and n2 nodes are equal
 func isEqualNode(n1, n2 Node) bool {
 	if n1.GetNodeType() != n2.GetNodeType() {
@@ -366,7 +517,7 @@ func isEqualMapProperty(p1, p2 reflect.Value) bool {
 		}
 		val1 := iter1.Value().Interface()
 		val2 := checkVal2.Interface()
-		if val1 != val2 {
+		if !reflect.DeepEqual(val1, val2) {
 			return false
 		}
 	}
@@ -382,7 +533,7 @@ func isEqualArrayProperty(p1, p2 reflect.Value) bool {
 	for i := 0; i < p1.Len(); i++ {
 		val1 := p1.Index(i).Interface()
 		val2 := p2.Index(i).Interface()
-		if val1 != val2 {
+		if !reflect.DeepEqual(val1, val2) {
 			return false
 		}
 	}
@@ -393,14 +544,14 @@ func isEqualArrayProperty(p1, p2 reflect.Value) bool {
 func (g graphHolder) debugPrint() {
 	mId := 0
 	mp := make(map[*graphHolderNode]int)
-	for m := range g {
+	for m := range g.nodes {
 		mId += 1
 		mp[m] = mId
 	}
 	lines := make([]string, 0)
 
 	left, right := 0, 0
-	for m := range g {
+	for m := range g.nodes {
 		left += 1
 		right = left
 		sLastInd := 0
@@ -26,6 +26,46 @@ type Validator interface {
 	ValidateUnknown(interface{}) (bool, error)
 }
 
+// Defaulter interface - optional extension of Validator-interface, which
+// is used to fill in absent properties of nodes and edges by default
+// values before validation; both methods should NOT mutate given entity
+// and should return new one (or given one if there is nothing to fill in)
+type Defaulter interface {
+	ApplyNodeDefaults(Node) Node
+	ApplyEdgeDefaults(Edge) Edge
+}
+
+// Keyer interface - optional extension of Validator-interface; any type
+// that can provide identity keys of nodes and edges (see Keys-struct)
+// implements that interface
+type Keyer interface {
+	GraphKeys() Keys
+}
+
+// Collector interface - optional extension of Validator-interface, which
+// is used to validate entity without interruption on the first error and
+// to collect ALL its errors (second argument limits amount of collected
+// errors; non-positive limit means there is no limit); returned error
+// should describe ALL collected errors
+type Collector interface {
+	ValidateAll(interface{}, int) (bool, error)
+}
+
+// ParentGetter interface - optional extension of Graph-interface; any graph
+// that can return ALL parents (main nodes) of given node in form of duplets
+// (edge and main node) implements that interface; otherwise parents are
+// derived from triplets of graph, which is much slower
+type ParentGetter interface {
+	GetNodeParents(Node) []Duplet
+}
+
+// Undirected interface - optional extension of Edge-interface; undirected
+// edge connects both of its nodes symmetrically, so Graph-interface treats
+// each of them as both main and subject node of such edge
+type Undirected interface {
+	IsUndirected() bool
+}
+
 // Node interface - represents graph node which can return his type's
 // name and properties
 type Node interface {
@@ -102,3 +142,15 @@ type Graph interface {
 	// exact subject node)
 	RemoveEdge(Triplet) bool
 }
+
+// Updater interface - optional extension of Graph-interface; any graph that
+// can replace its nodes and edges by identical ones (keeping interconnections)
+// implements that interface
+type Updater interface {
+	// Replaces a single node, which is identical to given node, by given node
+	// keeping all its interconnections
+	UpdateNode(Node) bool
+	// Replaces a single edge (which lies between exact main node and exact subject
+	// node), which is identical to edge of given triplet, by this edge
+	UpdateEdge(Triplet) bool
+}
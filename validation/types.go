@@ -29,11 +29,12 @@ func (h nodeHolder) GetProp(key string) (interface{}, bool) {
 	return v, ok
 }
 
-// Buffer type that implements Edge-interface and thus can be used to
-// mutate any given data to those interfaces values
+// Buffer type that implements Edge-interface (and Undirected-interface)
+// and thus can be used to mutate any given data to those interfaces values
 type edgeHolder struct {
-	typ   string
-	props map[string]interface{}
+	typ        string
+	props      map[string]interface{}
+	undirected bool
 }
 
 // Returns type name of edge like it is considered as edge
@@ -58,6 +59,11 @@ func (h edgeHolder) GetProp(key string) (interface{}, bool) {
 	return v, ok
 }
 
+// Returns true if edge connects its nodes symmetrically
+func (h edgeHolder) IsUndirected() bool {
+	return h.undirected
+}
+
 // Buffer type that implements Triplet interface and thus can be used to
 // mutate 3 given Entity-interface values to Triplet-interface value
 type tripletHolder struct {
@@ -98,9 +104,27 @@ func (d dupletHolder) Node() Node {
 	return d.n
 }
 
+// Identity keys of nodes and edges - Nodes and Edges contain names of key
+// properties by type names of nodes and edges accordingly; two nodes (or
+// edges) of the same type with key properties are identical within graph
+// if values of their key properties are equal (other properties are
+// ignored); if type has no keys or any of key properties is absent -
+// identity is based on equality of ALL properties
+type Keys struct {
+	Nodes map[string][]string
+	Edges map[string][]string
+}
+
 // Buffer type that implements Graph-interface; a little more convinient
-// than slice-based type
-type graphHolder map[*graphHolderNode]struct{}
+// than slice-based type; keys contains identity keys of nodes and edges
+type graphHolder struct {
+	nodes map[*graphHolderNode]struct{}
+	keys  Keys
+}
+
+// for auto check of interface implementation
+var _ ParentGetter = graphHolder{}
+var _ Updater = graphHolder{}
 
 // Buffer type for graphHolder-struct, which represents node within graph;
 // contains node itself and all its interconnections within graph
@@ -120,6 +144,12 @@ func (g graphHolder) GetNodeChilds(n Node) []Duplet {
 	return g.getNodeChilds(n)
 }
 
+// Returns ALL main nodes of given n subject node in form of edge and main
+// node pairs (duplets)
+func (g graphHolder) GetNodeParents(n Node) []Duplet {
+	return g.getNodeParents(n)
+}
+
 // Returns ALL possible main node, edge and subject node threes (triplets)
 func (g graphHolder) GetTriplets() []Triplet {
 	blankFilters := make([]string, 0, 3)
@@ -177,3 +207,16 @@ func (g graphHolder) AddTriplet(tr Triplet) bool {
 func (g graphHolder) RemoveEdge(tr Triplet) bool {
 	return g.deleteEdge(tr)
 }
+
+// Replaces a single node, which is identical to n node (see Keys-struct),
+// by n node keeping all its interconnections
+func (g graphHolder) UpdateNode(n Node) bool {
+	return g.updateNode(n)
+}
+
+// Replaces a single edge (between exact main node and exact subject node
+// enumerated in tr triplet), which is identical to edge of tr triplet (see
+// Keys-struct), by edge of tr triplet
+func (g graphHolder) UpdateEdge(tr Triplet) bool {
+	return g.updateEdge(tr)
+}
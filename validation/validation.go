@@ -18,6 +18,31 @@ func NewEdge(typ string, props map[string]interface{}) Edge {
 	}
 }
 
+// Creates and returns new Edge-interface value with typ type name and
+// props properties, which connects its nodes symmetrically (see
+// Undirected-interface)
+func NewUndirectedEdge(typ string, props map[string]interface{}) Edge {
+	return edgeHolder{
+		typ:        typ,
+		props:      props,
+		undirected: true,
+	}
+}
+
+// Returns true if e edge implements Undirected-interface and connects its
+// nodes symmetrically
+func IsUndirected(e Edge) bool {
+	u, ok := e.(Undirected)
+	return ok && u.IsUndirected()
+}
+
+// Returns true if n1 and n2 nodes are identical - they have equal types
+// and ALL their properties are equal (the same way as Graph-interface
+// finds identity of nodes)
+func IsEqualNode(n1, n2 Node) bool {
+	return isEqualNode(n1, n2)
+}
+
 // Creates and returns new Triplet-interface with m as main node entity,
 // s as subject node entity and e as edge entity
 func NewTriplet(m, s Node, e Edge) Triplet {
@@ -71,7 +96,17 @@ func NewDuplet(n Node, e Edge) Duplet {
 //
 //	graph := NewGraph(nil, tripletSlice...)
 func NewGraph(ns []Node, gr ...Triplet) Graph {
-	res := make(graphHolder, 0)
+	return NewKeyedGraph(Keys{}, ns, gr...)
+}
+
+// Does the same as NewGraph, but identity of nodes and edges within
+// returned Graph-interface is based on keys (see Keys-struct) instead of
+// equality of ALL their properties
+func NewKeyedGraph(keys Keys, ns []Node, gr ...Triplet) Graph {
+	res := graphHolder{
+		nodes: make(map[*graphHolderNode]struct{}),
+		keys:  keys,
+	}
 	for _, n := range ns {
 		res.insertNode(n)
 	}
@@ -102,3 +137,75 @@ func Validate(vr Validator, v interface{}) (bool, error) {
 		return vr.ValidateUnknown(val)
 	}
 }
+
+// Validates v (the same way as Validate) using vr without interruption on
+// the first error and returns true and nil on success or error which
+// describes ALL (up to limit; non-positive limit means there is no limit)
+// found errors; if vr doesn't implement Collector-interface works exactly
+// as Validate
+func ValidateAll(vr Validator, v interface{}, limit int) (bool, error) {
+	c, ok := vr.(Collector)
+	if !ok {
+		return Validate(vr, v)
+	}
+	return c.ValidateAll(v, limit)
+}
+
+// Returns copy of n node where absent properties are filled in by default
+// values using vr; returns n itself if vr doesn't implement Defaulter-interface
+func ApplyDefaults(vr Validator, n Node) Node {
+	d, ok := vr.(Defaulter)
+	if !ok || n == nil {
+		return n
+	}
+	return d.ApplyNodeDefaults(n)
+}
+
+// Returns copy of e edge where absent properties are filled in by default
+// values using vr; returns e itself if vr doesn't implement Defaulter-interface
+func ApplyEdgeDefaults(vr Validator, e Edge) Edge {
+	d, ok := vr.(Defaulter)
+	if !ok || e == nil {
+		return e
+	}
+	return d.ApplyEdgeDefaults(e)
+}
+
+// Returns identity keys of nodes and edges provided by vr; returns empty
+// Keys-struct if vr doesn't implement Keyer-interface
+func GraphKeys(vr Validator) Keys {
+	k, ok := vr.(Keyer)
+	if !ok {
+		return Keys{}
+	}
+	return k.GraphKeys()
+}
+
+// Returns new Graph-interface value which is built from the gr nodes and
+// edges where absent properties are filled in by default values using vr;
+// returns gr itself if vr doesn't implement Defaulter-interface
+func ApplyGraphDefaults(vr Validator, gr Graph) Graph {
+	if _, ok := vr.(Defaulter); !ok {
+		return gr
+	}
+	nodes := gr.GetNodes()
+	ns := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		ns = append(ns, ApplyDefaults(vr, n))
+	}
+	triplets := gr.GetTriplets()
+	trs := make([]Triplet, 0, len(triplets))
+	for _, tr := range triplets {
+		trs = append(trs, NewTriplet(
+			ApplyDefaults(vr, tr.Main()),
+			ApplyDefaults(vr, tr.Subj()),
+			ApplyEdgeDefaults(vr, tr.Edge()),
+		))
+	}
+	keys := Keys{}
+	if g, ok := gr.(graphHolder); ok {
+		// keeps identity of nodes and edges within new graph
+		keys = g.keys
+	}
+	return NewKeyedGraph(keys, ns, trs...)
+}